@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/config"
 	router "github.com/BabichevDima/2025-07-30-archive-service/internal/http"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/http/handlers"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/http/middleware"
@@ -22,11 +24,49 @@ func main() {
 	logger.Init()
 	defer logger.L.Sync()
 
-	storPath := "./storage"
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	var taskRepo repository.TaskStore
+	switch cfg.Storage.Driver {
+	case "memory":
+		taskRepo = repository.NewTaskRepository(cfg.Tasks.MaxFiles, cfg.Tasks.MaxSize)
+	case "bolt":
+		boltRepo, err := repository.NewBoltTaskRepository(cfg.Storage.DBPath, cfg.Tasks.MaxFiles, cfg.Tasks.MaxSize)
+		if err != nil {
+			logger.Fatal("Failed to open task storage", zap.String("path", cfg.Storage.DBPath), zap.Error(err))
+		}
+		defer boltRepo.Close()
+		taskRepo = boltRepo
+	}
+	logger.Info("Task storage initialized", zap.String("storage", cfg.Storage.Driver))
 
-	taskRepo := repository.NewTaskRepository()
-	archiveService := service.NewArchiveServiceImpl(taskRepo, storPath)
-	taskUsecase := usecase.NewTaskUsecase(taskRepo, archiveService, 3)
+	filePolicy := cfg.Policy.Policy()
+	guard, err := cfg.Download.Guard()
+	if err != nil {
+		logger.Fatal("Invalid download settings", zap.Error(err))
+	}
+	archiveService := service.NewArchiveServiceImpl(taskRepo, cfg.Storage, cfg.Tasks, cfg.Download, filePolicy, guard)
+	taskUsecase := usecase.NewTaskUsecase(taskRepo, archiveService, cfg.Tasks, cfg.Retention, filePolicy)
+
+	recovery, err := taskUsecase.Recover(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to recover tasks", zap.Error(err))
+	}
+	logger.Info("Task recovery finished",
+		zap.Int("pending", recovery.Pending),
+		zap.Int("requeued", recovery.Requeued),
+		zap.Int("interrupted", recovery.Interrupted),
+		zap.Int("cleaned_up", recovery.CleanedUp),
+		zap.Int("revalidated", recovery.Revalidated),
+		zap.Int("orphans", recovery.Orphans),
+	)
+	taskUsecase.Start()
 	taskHandler := handlers.NewTaskHandler(taskUsecase)
 
 	mux := http.NewServeMux()
@@ -34,11 +74,11 @@ func main() {
 	handler := middleware.RequestLogger(logger.L, mux)
 
 	server := &http.Server{
-		Addr:         ":8080",
+		Addr:         cfg.HTTP.Addr,
 		Handler:      handler,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  15 * time.Second,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
 	}
 
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
@@ -48,7 +88,7 @@ func main() {
 	go func() {
 		<-sig
 
-		shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(serverCtx, cfg.HTTP.ShutdownTimeout)
 		defer cancel()
 
 		go func() {
@@ -62,6 +102,9 @@ func main() {
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			logger.Error("Server shutdown failed", zap.Error(err))
 		}
+		if err := taskUsecase.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Archive workers shutdown failed", zap.Error(err))
+		}
 		serverStopCtx()
 	}()
 
@@ -0,0 +1,117 @@
+// Package archive writes task archives in the supported output formats and
+// reads them back to check their integrity.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnknownFormat is returned by ParseFormat for unsupported formats.
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// MaxNameLength is the longest entry name most file systems accept.
+const MaxNameLength = 255
+
+// CheckName reports why name cannot be the name of an archive entry. Names
+// are single path elements of printable UTF-8, so that every entry extracts
+// into the target directory on any system.
+func CheckName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("empty")
+	case name == "." || name == "..", strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%q must not be a path", name)
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("longer than %d bytes", MaxNameLength)
+	case !utf8.ValidString(name), strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return fmt.Errorf("%q must be printable UTF-8", name)
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("%q must not start or end with a space", name)
+	}
+	return nil
+}
+
+// Format is an archive output format. Its value is also the file extension
+// without the leading dot.
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTar    Format = "tar"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+)
+
+// Formats lists every supported format, the default first.
+var Formats = []Format{FormatZip, FormatTar, FormatTarGz, FormatTarZst}
+
+// ParseFormat returns the format with the given name. An empty name selects
+// ZIP, the format of tasks created before formats were selectable.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatZip, nil
+	}
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, name)
+}
+
+// Extension returns the file extension including the leading dot.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ContentType returns the media type archives of this format are served as.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatTar:
+		return "application/x-tar"
+	case FormatTarGz:
+		return "application/gzip"
+	case FormatTarZst:
+		return "application/zstd"
+	default:
+		return "application/zip"
+	}
+}
+
+// Writer adds files to an archive. Close finishes the archive but does not
+// close the underlying writer.
+type Writer interface {
+	// Create starts a regular file entry and returns the writer its content
+	// is written to. At most size bytes may be written; formats that record
+	// the size up front pad a shorter entry with zeros. A negative size means
+	// the size is unknown, such entries are buffered in a temporary file by
+	// formats that record the size up front.
+	Create(name string, size int64, modTime time.Time) (io.Writer, error)
+	Close() error
+}
+
+// NewWriter returns a Writer that writes an archive of the given format to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatZip:
+		return newZipWriter(w), nil
+	case FormatTar:
+		return newTarWriter(w), nil
+	case FormatTarGz:
+		return newTarGzWriter(w), nil
+	case FormatTarZst:
+		tw, err := newTarZstWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return tw, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
@@ -0,0 +1,195 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	name    string
+	size    int64
+	content []byte
+}
+
+func TestRoundTrip(t *testing.T) {
+	random := make([]byte, 64<<10)
+	rand.NewChaCha8([32]byte{1}).Read(random)
+
+	entries := []testEntry{
+		{"a.pdf", 9, []byte("%PDF-1.7\n")},
+		{"random.bin", int64(len(random)), random},
+		{"unknown size.txt", -1, []byte("size not known up front")},
+		{"empty", 0, nil},
+		{"отчет.pdf", -1, []byte("%PDF")},
+	}
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			data := writeArchive(t, format, entries)
+
+			var got []testEntry
+			err := Walk(format, bytes.NewReader(data), int64(len(data)), func(name string, content io.Reader) error {
+				b, err := io.ReadAll(content)
+				got = append(got, testEntry{name: name, content: b})
+				return err
+			})
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+
+			if len(got) != len(entries) {
+				t.Fatalf("Walk() found %d entries, want %d", len(got), len(entries))
+			}
+			for i, want := range entries {
+				if got[i].name != want.name || !bytes.Equal(got[i].content, want.content) {
+					t.Errorf("entry %d = %q (%d bytes), want %q (%d bytes)", i, got[i].name, len(got[i].content), want.name, len(want.content))
+				}
+			}
+		})
+	}
+}
+
+func TestShortEntry(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			data := writeArchive(t, format, []testEntry{{"short.txt", 8, []byte("abc")}})
+
+			var content []byte
+			err := Walk(format, bytes.NewReader(data), int64(len(data)), func(name string, r io.Reader) error {
+				var err error
+				content, err = io.ReadAll(r)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+
+			want := []byte("abc")
+			if format != FormatZip {
+				// Tar records the size up front and pads the entry.
+				want = []byte("abc\x00\x00\x00\x00\x00")
+			}
+			if !bytes.Equal(content, want) {
+				t.Errorf("content = %q, want %q", content, want)
+			}
+		})
+	}
+}
+
+func TestWalkCorrupt(t *testing.T) {
+	random := make([]byte, 64<<10)
+	rand.NewChaCha8([32]byte{2}).Read(random)
+
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			data := writeArchive(t, format, []testEntry{{"random.bin", int64(len(random)), random}})
+
+			// Plain tar only checksums its headers.
+			at := len(data) / 2
+			if format == FormatTar {
+				at = 10
+			}
+			data[at] ^= 0xff
+
+			err := Walk(format, bytes.NewReader(data), int64(len(data)), func(string, io.Reader) error {
+				return nil
+			})
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Walk() error = %v, want ErrCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestWalkPassesCallbackError(t *testing.T) {
+	errStop := errors.New("stop")
+	data := writeArchive(t, FormatTarGz, []testEntry{{"a.txt", 1, []byte("a")}})
+
+	err := Walk(FormatTarGz, bytes.NewReader(data), int64(len(data)), func(string, io.Reader) error {
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Walk() error = %v, want the callback error", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatZip, false},
+		{"zip", FormatZip, false},
+		{"TAR.GZ", FormatTarGz, false},
+		{"tar.zst", FormatTarZst, false},
+		{"rar", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ParseFormat(%q) = %q, %v, want %q, wantErr %v", tt.name, got, err, tt.want, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnknownFormat) {
+				t.Errorf("ParseFormat(%q) error = %v, want ErrUnknownFormat", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"report.pdf", true},
+		{"отчет июль.pdf", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"a/b.pdf", false},
+		{`..\..\evil.pdf`, false},
+		{strings.Repeat("a", MaxNameLength), true},
+		{strings.Repeat("a", MaxNameLength+1), false},
+		{"a\x00.pdf", false},
+		{"\xff.pdf", false},
+		{" a.pdf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckName(tt.name); (err == nil) != tt.valid {
+				t.Errorf("CheckName(%q) = %v, want valid %v", tt.name, err, tt.valid)
+			}
+		})
+	}
+}
+
+func writeArchive(t *testing.T, format Format, entries []testEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	modTime := time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC)
+	for _, entry := range entries {
+		ew, err := w.Create(entry.name, entry.size, modTime)
+		if err != nil {
+			t.Fatalf("Create(%q) error = %v", entry.name, err)
+		}
+		if _, err := ew.Write(entry.content); err != nil {
+			t.Fatalf("Write(%q) error = %v", entry.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
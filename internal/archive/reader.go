@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrCorrupt is returned by Walk for archives that cannot be read back or
+// whose checksums do not match their content.
+var ErrCorrupt = errors.New("archive is corrupt")
+
+// Walk reads back an archive of the given format and calls fn with the name
+// and content of every regular file entry. The checksums the format carries
+// (ZIP CRCs, tar header checksums, gzip and zstd frame checksums) are checked
+// along the way; content fn leaves unread is read on its behalf. Read errors
+// are wrapped in ErrCorrupt, errors returned by fn are passed through.
+func Walk(format Format, r io.ReaderAt, size int64, fn func(name string, content io.Reader) error) error {
+	switch format {
+	case FormatZip:
+		return walkZip(r, size, fn)
+	case FormatTar, FormatTarGz, FormatTarZst:
+		return walkTar(format, io.NewSectionReader(r, 0, size), fn)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+func walkZip(r io.ReaderAt, size int64, fn func(name string, content io.Reader) error) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return corrupt(err)
+	}
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return corrupt(fmt.Errorf("%s: %w", file.Name, err))
+		}
+		err = visit(file.Name, rc, fn)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(format Format, r io.Reader, fn func(name string, content io.Reader) error) error {
+	switch format {
+	case FormatTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return corrupt(err)
+		}
+		defer gz.Close()
+		r = gz
+	case FormatTarZst:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return corrupt(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return corrupt(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(header.Name, tr, fn); err != nil {
+			return err
+		}
+	}
+
+	// The compressed stream is only checked once it is read to the end.
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return corrupt(err)
+	}
+	return nil
+}
+
+// visit passes an entry to fn and reads whatever fn left, so checksums at
+// the end of the entry are verified.
+func visit(name string, content io.Reader, fn func(name string, content io.Reader) error) error {
+	entry := &entryReader{name: name, r: content}
+	if err := fn(name, entry); err != nil {
+		return err
+	}
+	_, err := io.Copy(io.Discard, entry)
+	return err
+}
+
+// entryReader marks read errors of an entry as corruption.
+type entryReader struct {
+	name string
+	r    io.Reader
+}
+
+func (e *entryReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = corrupt(fmt.Errorf("%s: %w", e.name, err))
+	}
+	return n, err
+}
+
+func corrupt(err error) error {
+	if errors.Is(err, ErrCorrupt) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrCorrupt, err)
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarWriter writes a tarball, optionally through a compressor that is closed
+// together with the archive.
+type tarWriter struct {
+	tw         *tar.Writer
+	compressor io.WriteCloser
+	// entry is the entry being written, if any.
+	entry *tarEntry
+	// spooled is the entry of unknown size being written, if any.
+	spooled *spooledEntry
+}
+
+// tarEntry counts the bytes still owed to the size in the entry header.
+type tarEntry struct {
+	tw        *tar.Writer
+	remaining int64
+}
+
+func (e *tarEntry) Write(p []byte) (int, error) {
+	n, err := e.tw.Write(p)
+	e.remaining -= int64(n)
+	return n, err
+}
+
+// pad fills the rest of a short entry with zeros.
+func (e *tarEntry) pad() error {
+	if e.remaining <= 0 {
+		return nil
+	}
+	_, err := io.CopyN(e, zeros{}, e.remaining)
+	return err
+}
+
+// spooledEntry buffers an entry of unknown size until its header can be
+// written.
+type spooledEntry struct {
+	header *tar.Header
+	file   *os.File
+}
+
+func (e *spooledEntry) Write(p []byte) (int, error) {
+	return e.file.Write(p)
+}
+
+// flush writes the header with the final size and the buffered content.
+func (e *spooledEntry) flush(tw *tar.Writer) error {
+	defer os.Remove(e.file.Name())
+	defer e.file.Close()
+
+	size, err := e.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := e.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	e.header.Size = size
+	if err := tw.WriteHeader(e.header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, e.file)
+	return err
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func newTarWriter(w io.Writer) *tarWriter {
+	return &tarWriter{tw: tar.NewWriter(w)}
+}
+
+func newTarGzWriter(w io.Writer) *tarWriter {
+	compressor := gzip.NewWriter(w)
+	return &tarWriter{tw: tar.NewWriter(compressor), compressor: compressor}
+}
+
+func newTarZstWriter(w io.Writer) (*tarWriter, error) {
+	compressor, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &tarWriter{tw: tar.NewWriter(compressor), compressor: compressor}, nil
+}
+
+func (w *tarWriter) Create(name string, size int64, modTime time.Time) (io.Writer, error) {
+	if err := w.finishEntry(); err != nil {
+		return nil, err
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+	}
+	if size < 0 {
+		file, err := os.CreateTemp("", "archive-entry-*")
+		if err != nil {
+			return nil, err
+		}
+		w.spooled = &spooledEntry{header: header, file: file}
+		return w.spooled, nil
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	w.entry = &tarEntry{tw: w.tw, remaining: size}
+	return w.entry, nil
+}
+
+func (w *tarWriter) finishEntry() error {
+	if w.spooled != nil {
+		err := w.spooled.flush(w.tw)
+		w.spooled = nil
+		return err
+	}
+	if w.entry == nil {
+		return nil
+	}
+	err := w.entry.pad()
+	w.entry = nil
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	err := errors.Join(w.finishEntry(), w.tw.Close())
+	if w.compressor != nil {
+		err = errors.Join(err, w.compressor.Close())
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"archive/zip"
+	"io"
+	"time"
+)
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func newZipWriter(w io.Writer) *zipWriter {
+	return &zipWriter{zw: zip.NewWriter(w)}
+}
+
+func (w *zipWriter) Create(name string, size int64, modTime time.Time) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	}
+	if size >= 0 {
+		header.UncompressedSize64 = uint64(size)
+	}
+	header.SetMode(0644)
+
+	return w.zw.CreateHeader(header)
+}
+
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
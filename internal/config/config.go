@@ -0,0 +1,395 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
+	"github.com/BabichevDima/2025-07-30-archive-service/pkg/safehttp"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is prepended to every environment variable read by Load.
+const EnvPrefix = "ARCHIVE_"
+
+type Config struct {
+	HTTP      HTTPConfig      `yaml:"http"`
+	Storage   StorageConfig   `yaml:"storage"`
+	Tasks     TasksConfig     `yaml:"tasks"`
+	Download  DownloadConfig  `yaml:"download"`
+	Policy    PolicyConfig    `yaml:"policy"`
+	Retention RetentionConfig `yaml:"retention"`
+}
+
+type HTTPConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type StorageConfig struct {
+	// Driver selects the task repository: "memory" or "bolt".
+	Driver string `yaml:"driver"`
+	// Path is the directory for archives and temporary files.
+	Path string `yaml:"path"`
+	// DBPath is the bolt database file, used only by the bolt driver.
+	DBPath string `yaml:"db_path"`
+}
+
+type TasksConfig struct {
+	// MaxActive limits the number of tasks queued or being archived. New
+	// tasks are rejected while it is reached.
+	MaxActive int `yaml:"max_active"`
+	// MaxFiles is the maximum number of files per task.
+	MaxFiles int `yaml:"max_files"`
+	// AutoFinalize starts archiving as soon as a task has MaxFiles files.
+	AutoFinalize bool `yaml:"auto_finalize"`
+	// IdleTimeout finalizes a task that received no files for this long,
+	// zero disables it. Empty tasks are never finalized automatically.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// Workers is the size of the archiving worker pool.
+	Workers int `yaml:"workers"`
+	// MaxSize limits the total size of a task's files in bytes, zero
+	// disables the limit.
+	MaxSize int64 `yaml:"max_size"`
+}
+
+type DownloadConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+	// SniffBytes is how many leading bytes are inspected to detect the file type.
+	SniffBytes int64 `yaml:"sniff_bytes"`
+	// TaskParallelism limits concurrent downloads within a single task.
+	TaskParallelism int `yaml:"task_parallelism"`
+	// MaxFileSize limits the size of a single file in bytes, zero disables
+	// the limit.
+	MaxFileSize int64 `yaml:"max_file_size"`
+	// MaxConcurrent limits concurrent downloads across the whole service.
+	MaxConcurrent int         `yaml:"max_concurrent"`
+	Retry         RetryConfig `yaml:"retry"`
+	// AllowedSchemes lists the URL schemes files may be fetched with.
+	AllowedSchemes []string `yaml:"allowed_schemes"`
+	// TrustedHosts are host names ("*.corp.example" matches subdomains), IPs
+	// or CIDR ranges that may be fetched even though they are not public.
+	TrustedHosts []string `yaml:"trusted_hosts"`
+	MaxRedirects int      `yaml:"max_redirects"`
+}
+
+// Guard builds the checks applied to every outbound request.
+func (c DownloadConfig) Guard() (*safehttp.Guard, error) {
+	return safehttp.NewGuard(c.AllowedSchemes, c.TrustedHosts)
+}
+
+// RetryConfig controls retries of transient download failures.
+type RetryConfig struct {
+	// MaxAttempts includes the first try; 1 disables retries.
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+	// Jitter randomizes every delay by up to this fraction in either direction.
+	Jitter float64 `yaml:"jitter"`
+}
+
+// RetentionConfig controls how long finished tasks and their files are
+// kept. A zero TTL keeps tasks in that status forever.
+type RetentionConfig struct {
+	Completed time.Duration `yaml:"completed"`
+	Failed    time.Duration `yaml:"failed"`
+	Cancelled time.Duration `yaml:"cancelled"`
+	// SweepInterval is how often expired tasks are looked for.
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+}
+
+// PolicyConfig lists which files may be archived. Types are exact MIME types
+// or families like "image/*", extensions may be given with or without the
+// leading dot. Deny lists win over allow lists; an empty allow list allows
+// everything that is not denied.
+type PolicyConfig struct {
+	AllowTypes      []string `yaml:"allow_types"`
+	DenyTypes       []string `yaml:"deny_types"`
+	AllowExtensions []string `yaml:"allow_extensions"`
+	DenyExtensions  []string `yaml:"deny_extensions"`
+}
+
+// Policy builds the normalized file policy.
+func (c PolicyConfig) Policy() policy.Policy {
+	return policy.New(c.AllowTypes, c.DenyTypes, c.AllowExtensions, c.DenyExtensions)
+}
+
+// Default returns the configuration used when nothing else is specified.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     15 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver: "bolt",
+			Path:   "./storage",
+			DBPath: "./storage/tasks.db",
+		},
+		Tasks: TasksConfig{
+			MaxActive:    3,
+			MaxFiles:     3,
+			AutoFinalize: true,
+			Workers:      3,
+			MaxSize:      300 << 20,
+		},
+		Download: DownloadConfig{
+			Timeout:         10 * time.Second,
+			SniffBytes:      512,
+			MaxFileSize:     100 << 20,
+			TaskParallelism: 3,
+			MaxConcurrent:   8,
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelay:   500 * time.Millisecond,
+				MaxDelay:    10 * time.Second,
+				Jitter:      0.2,
+			},
+			AllowedSchemes: []string{"http", "https"},
+			TrustedHosts:   []string{},
+			MaxRedirects:   5,
+		},
+		Policy: PolicyConfig{
+			AllowTypes: []string{"application/pdf", "image/jpeg"},
+		},
+		Retention: RetentionConfig{
+			Completed:     24 * time.Hour,
+			Failed:        time.Hour,
+			Cancelled:     time.Hour,
+			SweepInterval: time.Minute,
+		},
+	}
+}
+
+// setting binds a configuration field to its command line flag and
+// environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *Config) any
+}
+
+var settings = []setting{
+	{"addr", "HTTP_ADDR", "HTTP listen address", func(c *Config) any { return &c.HTTP.Addr }},
+	{"read-timeout", "HTTP_READ_TIMEOUT", "HTTP read timeout", func(c *Config) any { return &c.HTTP.ReadTimeout }},
+	{"write-timeout", "HTTP_WRITE_TIMEOUT", "HTTP write timeout", func(c *Config) any { return &c.HTTP.WriteTimeout }},
+	{"idle-timeout", "HTTP_IDLE_TIMEOUT", "HTTP keep-alive idle timeout", func(c *Config) any { return &c.HTTP.IdleTimeout }},
+	{"shutdown-timeout", "HTTP_SHUTDOWN_TIMEOUT", "graceful shutdown timeout", func(c *Config) any { return &c.HTTP.ShutdownTimeout }},
+	{"storage", "STORAGE_DRIVER", "task storage backend: memory or bolt", func(c *Config) any { return &c.Storage.Driver }},
+	{"storage-path", "STORAGE_PATH", "directory for archives and temporary files", func(c *Config) any { return &c.Storage.Path }},
+	{"db", "STORAGE_DB_PATH", "path to the task database (bolt storage only)", func(c *Config) any { return &c.Storage.DBPath }},
+	{"max-tasks", "TASKS_MAX_ACTIVE", "maximum number of tasks queued or being archived", func(c *Config) any { return &c.Tasks.MaxActive }},
+	{"max-files", "TASKS_MAX_FILES", "maximum number of files per task", func(c *Config) any { return &c.Tasks.MaxFiles }},
+	{"auto-finalize", "TASKS_AUTO_FINALIZE", "start archiving once a task has max-files files", func(c *Config) any { return &c.Tasks.AutoFinalize }},
+	{"finalize-idle", "TASKS_IDLE_TIMEOUT", "finalize tasks that received no files for this long, 0 to disable", func(c *Config) any { return &c.Tasks.IdleTimeout }},
+	{"workers", "TASKS_WORKERS", "number of archiving workers", func(c *Config) any { return &c.Tasks.Workers }},
+	{"max-task-size", "TASKS_MAX_SIZE", "maximum total size of a task's files in bytes, 0 for no limit", func(c *Config) any { return &c.Tasks.MaxSize }},
+	{"download-timeout", "DOWNLOAD_TIMEOUT", "timeout for downloading a single file", func(c *Config) any { return &c.Download.Timeout }},
+	{"sniff-bytes", "DOWNLOAD_SNIFF_BYTES", "number of bytes inspected to detect the file type", func(c *Config) any { return &c.Download.SniffBytes }},
+	{"max-file-size", "DOWNLOAD_MAX_FILE_SIZE", "maximum size of a single file in bytes, 0 for no limit", func(c *Config) any { return &c.Download.MaxFileSize }},
+	{"task-parallelism", "DOWNLOAD_TASK_PARALLELISM", "concurrent downloads per task", func(c *Config) any { return &c.Download.TaskParallelism }},
+	{"max-downloads", "DOWNLOAD_MAX_CONCURRENT", "concurrent downloads across all tasks", func(c *Config) any { return &c.Download.MaxConcurrent }},
+	{"retry-attempts", "DOWNLOAD_RETRY_MAX_ATTEMPTS", "download attempts per file, including the first one", func(c *Config) any { return &c.Download.Retry.MaxAttempts }},
+	{"retry-base-delay", "DOWNLOAD_RETRY_BASE_DELAY", "delay before the first retry", func(c *Config) any { return &c.Download.Retry.BaseDelay }},
+	{"retry-max-delay", "DOWNLOAD_RETRY_MAX_DELAY", "maximum delay between retries", func(c *Config) any { return &c.Download.Retry.MaxDelay }},
+	{"retry-jitter", "DOWNLOAD_RETRY_JITTER", "random delay spread as a fraction of the delay", func(c *Config) any { return &c.Download.Retry.Jitter }},
+	{"allowed-schemes", "DOWNLOAD_ALLOWED_SCHEMES", "comma separated list of URL schemes files may be fetched with", func(c *Config) any { return &c.Download.AllowedSchemes }},
+	{"trusted-hosts", "DOWNLOAD_TRUSTED_HOSTS", "comma separated list of internal hosts, IPs or CIDR ranges that may be fetched", func(c *Config) any { return &c.Download.TrustedHosts }},
+	{"max-redirects", "DOWNLOAD_MAX_REDIRECTS", "maximum number of redirects followed per download", func(c *Config) any { return &c.Download.MaxRedirects }},
+	{"allow-types", "POLICY_ALLOW_TYPES", "comma separated list of accepted MIME types or families (image/*)", func(c *Config) any { return &c.Policy.AllowTypes }},
+	{"deny-types", "POLICY_DENY_TYPES", "comma separated list of rejected MIME types or families", func(c *Config) any { return &c.Policy.DenyTypes }},
+	{"allow-extensions", "POLICY_ALLOW_EXTENSIONS", "comma separated list of accepted file extensions", func(c *Config) any { return &c.Policy.AllowExtensions }},
+	{"deny-extensions", "POLICY_DENY_EXTENSIONS", "comma separated list of rejected file extensions", func(c *Config) any { return &c.Policy.DenyExtensions }},
+	{"retention-completed", "RETENTION_COMPLETED", "how long completed tasks and archives are kept, 0 to keep forever", func(c *Config) any { return &c.Retention.Completed }},
+	{"retention-failed", "RETENTION_FAILED", "how long failed tasks are kept, 0 to keep forever", func(c *Config) any { return &c.Retention.Failed }},
+	{"retention-cancelled", "RETENTION_CANCELLED", "how long cancelled tasks are kept, 0 to keep forever", func(c *Config) any { return &c.Retention.Cancelled }},
+	{"sweep-interval", "RETENTION_SWEEP_INTERVAL", "how often expired tasks are removed", func(c *Config) any { return &c.Retention.SweepInterval }},
+}
+
+// Load builds the configuration from defaults, an optional YAML file, the
+// environment and command line flags, each overriding the previous one.
+// The file is taken from the -config flag or the ARCHIVE_CONFIG variable.
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("archive-service", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(EnvPrefix+"CONFIG"), "path to a YAML configuration file")
+
+	type override struct {
+		s     setting
+		value string
+	}
+	var overrides []override
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, fmt.Sprintf("%s (env %s%s)", s.usage, EnvPrefix, s.env), func(v string) error {
+			overrides = append(overrides, override{s: s, value: v})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		v, ok := os.LookupEnv(EnvPrefix + s.env)
+		if !ok {
+			continue
+		}
+		if err := setValue(s.field(cfg), v); err != nil {
+			return nil, fmt.Errorf("invalid %s%s: %w", EnvPrefix, s.env, err)
+		}
+	}
+
+	for _, o := range overrides {
+		if err := setValue(o.s.field(cfg), o.value); err != nil {
+			return nil, fmt.Errorf("invalid -%s: %w", o.s.flag, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func setValue(field any, v string) error {
+	switch p := field.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *int64:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = d
+	case *[]string:
+		*p = splitList(v)
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+func splitList(v string) []string {
+	list := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr must not be empty")
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.write_timeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idle_timeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+
+	check(c.Storage.Driver == "memory" || c.Storage.Driver == "bolt",
+		"storage.driver must be memory or bolt, got %q", c.Storage.Driver)
+	check(c.Storage.Path != "", "storage.path must not be empty")
+	check(c.Storage.Driver != "bolt" || c.Storage.DBPath != "", "storage.db_path must not be empty for the bolt driver")
+
+	check(c.Tasks.MaxActive > 0, "tasks.max_active must be positive")
+	check(c.Tasks.MaxFiles > 0, "tasks.max_files must be positive")
+	check(c.Tasks.Workers > 0, "tasks.workers must be positive")
+	check(c.Tasks.MaxSize >= 0, "tasks.max_size must not be negative")
+	check(c.Tasks.IdleTimeout >= 0, "tasks.idle_timeout must not be negative")
+
+	check(c.Download.Timeout > 0, "download.timeout must be positive")
+	check(c.Download.SniffBytes > 0, "download.sniff_bytes must be positive")
+	check(c.Download.MaxFileSize >= 0, "download.max_file_size must not be negative")
+	check(c.Download.TaskParallelism > 0, "download.task_parallelism must be positive")
+	check(c.Download.MaxConcurrent > 0, "download.max_concurrent must be positive")
+	check(c.Download.Retry.MaxAttempts > 0, "download.retry.max_attempts must be positive")
+	check(c.Download.Retry.BaseDelay >= 0, "download.retry.base_delay must not be negative")
+	check(c.Download.Retry.MaxDelay >= c.Download.Retry.BaseDelay, "download.retry.max_delay must not be less than base_delay")
+	check(c.Download.Retry.Jitter >= 0 && c.Download.Retry.Jitter <= 1, "download.retry.jitter must be between 0 and 1")
+	check(c.Download.MaxRedirects >= 0, "download.max_redirects must not be negative")
+	if _, err := c.Download.Guard(); err != nil {
+		errs = append(errs, fmt.Errorf("download: %w", err))
+	}
+
+	check(c.Retention.Completed >= 0, "retention.completed must not be negative")
+	check(c.Retention.Failed >= 0, "retention.failed must not be negative")
+	check(c.Retention.Cancelled >= 0, "retention.cancelled must not be negative")
+	check(c.Retention.SweepInterval > 0, "retention.sweep_interval must be positive")
+
+	if err := c.Policy.Policy().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("policy: %w", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
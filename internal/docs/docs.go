@@ -15,24 +15,123 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/policy": {
+            "get": {
+                "description": "Возвращает действующие списки разрешенных и запрещенных MIME типов и расширений",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policy"
+                ],
+                "summary": "Получить политику типов файлов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.FilePolicyResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/tasks": {
             "get": {
-                "description": "Возвращает список всех задач архивации",
+                "description": "Возвращает страницу задач с фильтрами и сортировкой. Для следующей страницы передайте next_cursor из ответа в параметре cursor",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Получить список всех задач",
+                "summary": "Получить список задач",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Статусы через запятую, например Completed,Failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока названия без учета регистра",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы раньше (RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены не раньше (RFC 3339)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены раньше (RFC 3339)",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "name"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Размер страницы",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.ResponseTask"
-                            }
+                            "$ref": "#/definitions/dto.TaskListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
                         }
                     },
                     "500": {
@@ -44,7 +143,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Создает новую задачу для последующего добавления URL файлов",
+                "description": "Создает новую задачу. Если переданы urls, файлы добавляются в задачу и проверяются в фоне, как при POST /api/tasks/{id}/urls. Задача создается, только если все URL и имена файлов корректны, иначе возвращается результат по каждому URL. С finalize=true задача сразу ставится в очередь на архивацию. Формат архива задается полем format: zip (по умолчанию), tar, tar.gz или tar.zst. Задача с ephemeral=true не архивируется на сервере и скачивается только с stream=true. С manifest=true в конец архива добавляются manifest.json и SHA256SUMS",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,7 +169,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dto.ResponseTask"
+                            "$ref": "#/definitions/dto.CreateTaskResponse"
                         }
                     },
                     "400": {
@@ -79,6 +178,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.BadRequestError"
                         }
                     },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchErrorResponse"
+                        }
+                    },
                     "429": {
                         "description": "Too Many Requests",
                         "schema": {
@@ -94,16 +199,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tasks/{id}/status": {
+        "/api/tasks/{id}": {
             "get": {
-                "description": "Возвращает текущий статус задачи и ссылку на архив (если готов)",
+                "description": "Возвращает задачу со всеми файлами и их состоянием",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Получить статус задачи",
+                "summary": "Получить задачу",
                 "parameters": [
                     {
                         "type": "string",
@@ -117,8 +222,47 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.TaskStatusResponse"
+                            "$ref": "#/definitions/dto.ResponseTask"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundRequestError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.InternalServerError"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Незавершенная задача отменяется: загрузки прерываются, файлы удаляются, задача получает статус Cancelled. Завершенная задача удаляется вместе с архивом",
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Отменить или удалить задачу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -141,19 +285,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tasks/{id}/urls": {
-            "post": {
-                "description": "Добавляет URL файла для загрузки в указанную задачу",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/tasks/{id}/archive": {
+            "get": {
+                "description": "Отдает архив завершенной задачи в выбранном при создании формате. Поддерживает Range запросы для докачки и условные запросы по ETag. ETag содержит SHA-256 архива, он же передается в заголовках Digest и Repr-Digest.\nС stream=true архив не хранится: файлы задачи загружаются заново и сразу передаются в ответ (chunked). Итог передается в трейлерах X-Archive-Status (complete, partial или failed), X-Archive-Files, X-Archive-Failed и X-Archive-Errors (JSON со списком файлов, не попавших в архив или оборванных при передаче)",
                 "produces": [
-                    "application/json"
+                    "application/zip",
+                    "application/x-tar",
+                    "application/gzip",
+                    "application/zstd"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Добавить URL в задачу",
+                "summary": "Скачать готовый архив",
                 "parameters": [
                     {
                         "type": "string",
@@ -163,18 +307,39 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "URL файла",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.URLRequest"
-                        }
+                        "type": "string",
+                        "description": "Диапазон байт, например bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученного архива",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Собрать архив на лету, не сохраняя его",
+                        "name": "stream",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -188,6 +353,15 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.NotFoundRequestError"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConflictRequestError"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable"
+                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -202,85 +376,699 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.RequestTask": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
         },
-        "dto.ResponseTask": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "errors": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/api/tasks/{id}/finalize": {
+            "post": {
+                "description": "Закрывает задачу для новых файлов и ставит ее в очередь на архивацию. Задача должна содержать хотя бы один файл. Если очередь заполнена, возвращается 429 и задача остается открытой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Завершить добавление файлов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
-                },
-                "id": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
-                },
-                "urls": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResponseTask"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundRequestError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConflictRequestError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConstrainsErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerBusyRequestError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.InternalServerError"
+                        }
                     }
-                },
-                "zip_path": {
-                    "type": "string"
-                }
-            }
-        },
-        "dto.TaskStatusResponse": {
-            "type": "object",
-            "properties": {
-                "status": {
-                    "type": "string"
-                }
-            }
-        },
-        "dto.URLRequest": {
-            "type": "object",
-            "required": [
-                "url"
-            ],
-            "properties": {
-                "url": {
-                    "type": "string"
                 }
             }
         },
-        "response.BadRequestError": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "message": {
-                    "type": "string",
-                    "example": "Invalid request payload"
-                }
+        "/api/tasks/{id}/status": {
+            "get": {
+                "description": "Возвращает текущий статус задачи, прогресс загрузки файлов, ошибки и ссылку на архив (если готов)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Получить статус задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TaskStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundRequestError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{id}/urls": {
+            "post": {
+                "description": "Добавляет URL файла в указанную задачу. Файл загружается и проверяется в фоне: ответ возвращается сразу с файлом в состоянии pending, результат проверки отражается в состоянии файла в задаче",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Добавить URL в задачу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "URL файла и необязательное имя файла в архиве",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.URLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResponseItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundRequestError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConflictRequestError"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/response.RequestEntityTooLargeError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConstrainsErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tasks/{id}/verify": {
+            "post": {
+                "description": "Перечитывает архив завершенной задачи с диска: сверяет размер и SHA-256 с сохраненными при сборке, проверяет структуру и контрольные суммы формата (CRC в ZIP, gzip и zstd), а также содержимое каждого файла с проверенным при валидации.\nНайденные повреждения перечислены в problems, ok=false. Для архивов, собранных до появления хешей, проверяются только структура и содержимое файлов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Проверить целостность архива",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerifyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundRequestError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ConflictRequestError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.InternalServerError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.BatchErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Some URLs were rejected"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.URLResult"
+                    }
+                }
+            }
+        },
+        "dto.CreateTaskResponse": {
+            "type": "object",
+            "properties": {
+                "archive_sha256": {
+                    "type": "string"
+                },
+                "archive_size": {
+                    "description": "ArchiveSize and ArchiveSHA256 describe the completed archive.",
+                    "type": "integer"
+                },
+                "archive_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ephemeral": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is set for finished tasks that will be deleted.",
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ResponseItem"
+                    }
+                },
+                "manifest": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.URLResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FilePolicyResponse": {
+            "type": "object",
+            "properties": {
+                "allow_extensions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allow_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deny_extensions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deny_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.ItemError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "http_status"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RequestTask": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "ephemeral": {
+                    "description": "Ephemeral tasks are never archived on the server. They are downloaded\nwith stream=true, and a complete download completes the task.",
+                    "type": "boolean"
+                },
+                "finalize": {
+                    "description": "Finalize queues the task for archiving right after it is created.",
+                    "type": "boolean"
+                },
+                "format": {
+                    "description": "Format is the archive output format, ZIP by default.",
+                    "type": "string",
+                    "enum": [
+                        "zip",
+                        "tar",
+                        "tar.gz",
+                        "tar.zst"
+                    ]
+                },
+                "manifest": {
+                    "description": "Manifest adds manifest.json and SHA256SUMS with the provenance of\nevery file as the last entries of the archive.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "urls": {
+                    "description": "URLs are added to the task in order and validated in the background.\nThe task is created only if all of them are well-formed.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.URLRequest"
+                    }
+                }
+            }
+        },
+        "dto.ResponseAttempt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "transient": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.ResponseItem": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ResponseAttempt"
+                    }
+                },
+                "entry_name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_code": {
+                    "description": "ErrorCode is one of http_status, network, timeout, type_not_allowed,\ntoo_large, blocked, content_changed, cancelled or unknown.",
+                    "type": "string",
+                    "example": "http_status"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "index": {
+                    "description": "Index is the position of the item in the task.",
+                    "type": "integer"
+                },
+                "mime": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "sha256": {
+                    "description": "SHA256 is set once the file is archived.",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size is announced by the server during validation and is the actual\nsize once the file is archived.",
+                    "type": "integer"
+                },
+                "state": {
+                    "description": "State is pending until the file is validated, validating while it is\nprobed, validated until it is archived, downloading while it is written\ninto the archive, done once it is in the archive, or failed.",
+                    "type": "string",
+                    "example": "done"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ResponseTask": {
+            "type": "object",
+            "properties": {
+                "archive_sha256": {
+                    "type": "string"
+                },
+                "archive_size": {
+                    "description": "ArchiveSize and ArchiveSHA256 describe the completed archive.",
+                    "type": "integer"
+                },
+                "archive_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ephemeral": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is set for finished tasks that will be deleted.",
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ResponseItem"
+                    }
+                },
+                "manifest": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TaskListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "NextCursor is empty on the last page.",
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ResponseTask"
+                    }
+                },
+                "total": {
+                    "description": "Total is the number of tasks matching the filters on all pages.",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TaskProgress": {
+            "type": "object",
+            "properties": {
+                "bytes_downloaded": {
+                    "description": "BytesDownloaded is the total size of the files written into the\narchive.",
+                    "type": "integer"
+                },
+                "files_done": {
+                    "description": "FilesDone counts the files written into the archive.",
+                    "type": "integer"
+                },
+                "files_failed": {
+                    "type": "integer"
+                },
+                "files_total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TaskStatusResponse": {
+            "type": "object",
+            "properties": {
+                "archive_sha256": {
+                    "type": "string"
+                },
+                "archive_size": {
+                    "type": "integer"
+                },
+                "archive_url": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ItemError"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ResponseItem"
+                    }
+                },
+                "progress": {
+                    "$ref": "#/definitions/dto.TaskProgress"
+                },
+                "queue_position": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.URLRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "filename": {
+                    "description": "Filename is the name of the file inside the archive. By default it is\ntaken from the URL.",
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.URLResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is invalid_url or invalid_filename.",
+                    "type": "string",
+                    "example": "invalid_url"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is accepted, rejected or skipped. Skipped URLs were not\nadded because other URLs of the request were invalid.",
+                    "type": "string",
+                    "example": "accepted"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.VerifyResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "description": "Entries counts the files read from the archive.",
+                    "type": "integer"
+                },
+                "expected_sha256": {
+                    "type": "string"
+                },
+                "expected_size": {
+                    "description": "ExpectedSize and ExpectedSHA256 were recorded when the archive was\nbuilt. They are empty for archives built by earlier versions.",
+                    "type": "integer"
+                },
+                "ok": {
+                    "description": "OK is set if the archive is intact.",
+                    "type": "boolean"
+                },
+                "problems": {
+                    "description": "Problems describes the corruption found, if any.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "archive is corrupt: report.pdf: zip: checksum error"
+                    ]
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size and SHA256 describe the archive as it is on disk now.",
+                    "type": "integer"
+                }
+            }
+        },
+        "response.BadRequestError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Invalid request payload"
+                }
+            }
+        },
+        "response.ConflictRequestError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 409
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Archive is not ready yet"
+                }
             }
         },
         "response.ConstrainsErrorResponse": {
@@ -292,7 +1080,7 @@ const docTemplate = `{
                 },
                 "message": {
                     "type": "string",
-                    "example": "You can only upload up to 3 files per task"
+                    "example": "File limit per task reached"
                 }
             }
         },
@@ -322,6 +1110,19 @@ const docTemplate = `{
                 }
             }
         },
+        "response.RequestEntityTooLargeError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 413
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Task size limit reached"
+                }
+            }
+        },
         "response.ServerBusyRequestError": {
             "type": "object",
             "properties": {
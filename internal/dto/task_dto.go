@@ -6,23 +6,180 @@ import (
 
 type RequestTask struct {
 	Name string `json:"name" binding:"required"`
+	// URLs are added to the task in order and validated in the background.
+	// The task is created only if all of them are well-formed.
+	URLs []URLRequest `json:"urls,omitempty"`
+	// Finalize queues the task for archiving right after it is created.
+	Finalize bool `json:"finalize,omitempty"`
+	// Format is the archive output format, ZIP by default.
+	Format string `json:"format,omitempty" enums:"zip,tar,tar.gz,tar.zst"`
+	// Ephemeral tasks are never archived on the server. They are downloaded
+	// with stream=true, and a complete download completes the task.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+	// Manifest adds manifest.json and SHA256SUMS with the provenance of
+	// every file as the last entries of the archive.
+	Manifest bool `json:"manifest,omitempty"`
+}
+
+// CreateTaskResponse is the created task and the outcome of every URL of
+// the request.
+type CreateTaskResponse struct {
+	ResponseTask
+	Results []URLResult `json:"results,omitempty"`
+}
+
+// URLResult is the outcome of a single URL of a batch request.
+type URLResult struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename,omitempty"`
+	// Status is accepted, rejected or skipped. Skipped URLs were not
+	// added because other URLs of the request were invalid.
+	Status string `json:"status" example:"accepted"`
+	// Code is invalid_url or invalid_filename.
+	Code    string `json:"code,omitempty" example:"invalid_url"`
+	Message string `json:"message,omitempty"`
+}
+
+// BatchErrorResponse is returned when a task is not created because some of
+// its URLs or file names were malformed.
+type BatchErrorResponse struct {
+	Error   string      `json:"error" example:"Some URLs were rejected"`
+	Results []URLResult `json:"results"`
 }
 
 type ResponseTask struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Status    string    `json:"status"`
-	URLs      []string  `json:"urls"`
-	Errors    []string  `json:"errors"`
-	ZipPath   string    `json:"zip_path,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	Format     string         `json:"format"`
+	Ephemeral  bool           `json:"ephemeral,omitempty"`
+	Manifest   bool           `json:"manifest,omitempty"`
+	Items      []ResponseItem `json:"items"`
+	ArchiveURL string         `json:"archive_url,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	// ExpiresAt is set for finished tasks that will be deleted.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ArchiveSize and ArchiveSHA256 describe the completed archive.
+	ArchiveSize   int64  `json:"archive_size,omitempty"`
+	ArchiveSHA256 string `json:"archive_sha256,omitempty"`
+}
+
+// TaskListQuery selects a page of tasks. Zero values disable a filter.
+type TaskListQuery struct {
+	// Statuses keeps tasks with any of the given statuses.
+	Statuses []string
+	// Name keeps tasks whose name contains it, ignoring case.
+	Name          string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// Sort is one of created_at, updated_at or name.
+	Sort string
+	// Order is asc or desc.
+	Order string
+	Limit int
+	// Cursor is the next_cursor of the previous page.
+	Cursor string
+}
+
+// TaskListResponse is a page of tasks.
+type TaskListResponse struct {
+	Tasks []ResponseTask `json:"tasks"`
+	// Total is the number of tasks matching the filters on all pages.
+	Total int `json:"total"`
+	// NextCursor is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ResponseItem is the state of a single file of a task.
+type ResponseItem struct {
+	// Index is the position of the item in the task.
+	Index int    `json:"index"`
+	URL   string `json:"url"`
+	// State is pending until the file is validated, validating while it is
+	// probed, validated until it is archived, downloading while it is written
+	// into the archive, done once it is in the archive, or failed.
+	State string `json:"state" example:"done"`
+	MIME  string `json:"mime,omitempty" example:"application/pdf"`
+	// Size is announced by the server during validation and is the actual
+	// size once the file is archived.
+	Size int64 `json:"size,omitempty"`
+	// SHA256 is set once the file is archived.
+	SHA256 string `json:"sha256,omitempty"`
+	// ErrorCode is one of http_status, network, timeout, type_not_allowed,
+	// too_large, blocked, content_changed, cancelled or unknown.
+	ErrorCode string            `json:"error_code,omitempty" example:"http_status"`
+	Error     string            `json:"error,omitempty"`
+	Attempts  []ResponseAttempt `json:"attempts"`
+	Filename  string            `json:"filename,omitempty" example:"report.pdf"`
+	EntryName string            `json:"entry_name,omitempty" example:"report.pdf"`
+}
+
+type ResponseAttempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Transient  bool      `json:"transient,omitempty"`
 }
 
 type URLRequest struct {
 	URL string `json:"url" binding:"required,url"`
+	// Filename is the name of the file inside the archive. By default it is
+	// taken from the URL.
+	Filename string `json:"filename,omitempty" example:"report.pdf"`
 }
 
 type TaskStatusResponse struct {
-	Status string `json:"status"`
+	Status        string         `json:"status"`
+	QueuePosition int            `json:"queue_position,omitempty"`
+	Progress      TaskProgress   `json:"progress"`
+	Errors        []ItemError    `json:"errors"`
+	ArchiveURL    string         `json:"archive_url,omitempty"`
+	ArchiveSize   int64          `json:"archive_size,omitempty"`
+	ArchiveSHA256 string         `json:"archive_sha256,omitempty"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	Items         []ResponseItem `json:"items"`
+}
+
+type TaskProgress struct {
+	// FilesDone counts the files written into the archive.
+	FilesDone   int `json:"files_done"`
+	FilesFailed int `json:"files_failed"`
+	FilesTotal  int `json:"files_total"`
+	// BytesDownloaded is the total size of the files written into the
+	// archive.
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+}
+
+// ItemError describes a file that could not be archived.
+type ItemError struct {
+	URL     string `json:"url"`
+	Code    string `json:"code" example:"http_status"`
+	Message string `json:"message"`
+}
+
+// VerifyResponse is the outcome of reading back a completed archive.
+type VerifyResponse struct {
+	// OK is set if the archive is intact.
+	OK bool `json:"ok"`
+	// Size and SHA256 describe the archive as it is on disk now.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	// ExpectedSize and ExpectedSHA256 were recorded when the archive was
+	// built. They are empty for archives built by earlier versions.
+	ExpectedSize   int64  `json:"expected_size,omitempty"`
+	ExpectedSHA256 string `json:"expected_sha256,omitempty"`
+	// Entries counts the files read from the archive.
+	Entries int `json:"entries"`
+	// Problems describes the corruption found, if any.
+	Problems []string `json:"problems" example:"archive is corrupt: report.pdf: zip: checksum error"`
+}
+
+type FilePolicyResponse struct {
+	AllowTypes      []string `json:"allow_types"`
+	DenyTypes       []string `json:"deny_types"`
+	AllowExtensions []string `json:"allow_extensions"`
+	DenyExtensions  []string `json:"deny_extensions"`
 }
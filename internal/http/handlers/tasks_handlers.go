@@ -1,13 +1,25 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/http/response"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/usecase"
 )
 
@@ -35,13 +47,14 @@ func NewTaskHandler(u *usecase.TaskUsecase) *TaskHandler {
 
 // Create godoc
 // @Summary Создать новую задачу архивации
-// @Description Создает новую задачу для последующего добавления URL файлов
+// @Description Создает новую задачу. Если переданы urls, файлы добавляются в задачу и проверяются в фоне, как при POST /api/tasks/{id}/urls. Задача создается, только если все URL и имена файлов корректны, иначе возвращается результат по каждому URL. С finalize=true задача сразу ставится в очередь на архивацию. Формат архива задается полем format: zip (по умолчанию), tar, tar.gz или tar.zst. Задача с ephemeral=true не архивируется на сервере и скачивается только с stream=true. С manifest=true в конец архива добавляются manifest.json и SHA256SUMS
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param request body dto.RequestTask true "Данные для создания задачи"
-// @Success 201 {object} dto.ResponseTask
+// @Success 201 {object} dto.CreateTaskResponse
 // @Failure 400 {object} response.BadRequestError
+// @Failure 422 {object} dto.BatchErrorResponse
 // @Failure 429 {object} response.ServerBusyRequestError
 // @Failure 500 {object} response.InternalServerError
 // @Router /api/tasks [post]
@@ -65,10 +78,25 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	taskResponse, err := h.usecase.Create(request)
+	taskResponse, err := h.usecase.Create(r.Context(), request)
 	if err != nil {
+		var batchErr *usecase.BatchError
 		switch {
-		case strings.Contains(err.Error(), "server is busy"):
+		case errors.As(err, &batchErr):
+			log.Println(err)
+			response.RespondWithJSON(w, http.StatusUnprocessableEntity, dto.BatchErrorResponse{
+				Error:   "Some URLs were rejected",
+				Results: batchErr.Results,
+			})
+		case errors.Is(err, archive.ErrUnknownFormat):
+			response.RespondWithError(w, http.StatusBadRequest, "Unknown archive format", err)
+		case errors.Is(err, usecase.ErrEphemeralTask):
+			response.RespondWithError(w, http.StatusBadRequest, "Ephemeral tasks cannot be finalized", err)
+		case errors.Is(err, repository.ErrURLLimitReached):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, "File limit per task reached", err)
+		case errors.Is(err, usecase.ErrTaskEmpty):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, "Task has no files", err)
+		case errors.Is(err, usecase.ErrServerBusy):
 			response.RespondWithError(w, http.StatusTooManyRequests, "Server is busy", err)
 		default:
 			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
@@ -79,20 +107,37 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	response.RespondWithJSON(w, http.StatusCreated, taskResponse)
 }
 
-// GetAllTasks godoc
-// @Summary Получить список всех задач
-// @Description Возвращает список всех задач архивации
+// ListTasks godoc
+// @Summary Получить список задач
+// @Description Возвращает страницу задач с фильтрами и сортировкой. Для следующей страницы передайте next_cursor из ответа в параметре cursor
 // @Tags tasks
 // @Produce json
-// @Success 200 {array} dto.ResponseTask
+// @Param status query string false "Статусы через запятую, например Completed,Failed"
+// @Param name query string false "Подстрока названия без учета регистра"
+// @Param created_after query string false "Созданы не раньше (RFC 3339)"
+// @Param created_before query string false "Созданы раньше (RFC 3339)"
+// @Param updated_after query string false "Изменены не раньше (RFC 3339)"
+// @Param updated_before query string false "Изменены раньше (RFC 3339)"
+// @Param sort query string false "Поле сортировки" Enums(created_at, updated_at, name) default(created_at)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(desc)
+// @Param limit query int false "Размер страницы" minimum(1) maximum(100) default(20)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} dto.TaskListResponse
+// @Failure 400 {object} response.BadRequestError
 // @Failure 500 {object} response.InternalServerError
 // @Router /api/tasks [get]
-func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	tasksResponse, err := h.usecase.GetAllTasks()
+func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	query, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid query", err)
+		return
+	}
+
+	tasksResponse, err := h.usecase.ListTasks(r.Context(), query)
 	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), "unavailable"):
-			response.RespondWithError(w, http.StatusServiceUnavailable, "Service unavailable", err)
+		case errors.Is(err, usecase.ErrInvalidQuery):
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid query", err)
 		default:
 			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
 		}
@@ -101,17 +146,67 @@ func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 	response.RespondWithJSON(w, http.StatusOK, tasksResponse)
 }
 
+func parseListQuery(values url.Values) (dto.TaskListQuery, error) {
+	query := dto.TaskListQuery{
+		Name:   values.Get("name"),
+		Sort:   values.Get("sort"),
+		Order:  values.Get("order"),
+		Cursor: values.Get("cursor"),
+	}
+
+	for _, value := range values["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				query.Statuses = append(query.Statuses, status)
+			}
+		}
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("invalid limit %q", value)
+		}
+		query.Limit = limit
+	}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+	}
+	for _, t := range times {
+		value := values.Get(t.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("invalid %s %q: expected RFC 3339 time", t.name, value)
+		}
+		*t.dst = parsed
+	}
+
+	return query, nil
+}
+
 // AddURL godoc
 // @Summary Добавить URL в задачу
-// @Description Добавляет URL файла для загрузки в указанную задачу
+// @Description Добавляет URL файла в указанную задачу. Файл загружается и проверяется в фоне: ответ возвращается сразу с файлом в состоянии pending, результат проверки отражается в состоянии файла в задаче
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "ID задачи"
-// @Param request body dto.URLRequest true "URL файла"
-// @Success 204
+// @Param request body dto.URLRequest true "URL файла и необязательное имя файла в архиве"
+// @Success 202 {object} dto.ResponseItem
 // @Failure 400 {object} response.BadRequestError
 // @Failure 404 {object} response.NotFoundRequestError
+// @Failure 409 {object} response.ConflictRequestError
+// @Failure 413 {object} response.RequestEntityTooLargeError
 // @Failure 422 {object} response.ConstrainsErrorResponse
 // @Failure 500 {object} response.InternalServerError
 // @Router /api/tasks/{id}/urls [post]
@@ -136,26 +231,138 @@ func (h *TaskHandler) AddURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.usecase.AddURL(taskID, req.URL); err != nil {
+	itemResponse, err := h.usecase.AddURL(r.Context(), taskID, req.URL, req.Filename)
+	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), "not found"):
+		case errors.Is(err, usecase.ErrInvalidURL):
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid URL", err)
+		case errors.Is(err, usecase.ErrInvalidFilename):
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid file name", err)
+		case errors.Is(err, repository.ErrTaskNotFound):
 			response.RespondWithError(w, http.StatusNotFound, "Task not found or was deleted", err)
-		case strings.Contains(err.Error(), "Validation Error"):
-			response.RespondWithError(w, http.StatusUnprocessableEntity, "You can only upload up to 3 files per task", err)
-		case strings.Contains(err.Error(), "unavailable"):
-			response.RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("URL is not available: %v", req.URL), err)
+		case errors.Is(err, repository.ErrTaskClosed):
+			response.RespondWithError(w, http.StatusConflict, "Task does not accept new files", err)
+		case errors.Is(err, repository.ErrURLLimitReached):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, "File limit per task reached", err)
+		case errors.Is(err, repository.ErrSizeLimitReached):
+			response.RespondWithError(w, http.StatusRequestEntityTooLarge, "Task size limit reached", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusAccepted, itemResponse)
+}
+
+// GetTask godoc
+// @Summary Получить задачу
+// @Description Возвращает задачу со всеми файлами и их состоянием
+// @Tags tasks
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} dto.ResponseTask
+// @Failure 400 {object} response.BadRequestError
+// @Failure 404 {object} response.NotFoundRequestError
+// @Failure 500 {object} response.InternalServerError
+// @Router /api/tasks/{id} [get]
+func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "task ID is required", nil)
+		return
+	}
+
+	taskResponse, err := h.usecase.GetTask(r.Context(), taskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, taskResponse)
+}
+
+// DeleteTask godoc
+// @Summary Отменить или удалить задачу
+// @Description Незавершенная задача отменяется: загрузки прерываются, файлы удаляются, задача получает статус Cancelled. Завершенная задача удаляется вместе с архивом
+// @Tags tasks
+// @Param id path string true "ID задачи"
+// @Success 204
+// @Failure 400 {object} response.BadRequestError
+// @Failure 404 {object} response.NotFoundRequestError
+// @Failure 500 {object} response.InternalServerError
+// @Router /api/tasks/{id} [delete]
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "task ID is required", nil)
+		return
+	}
+
+	if err := h.usecase.Delete(r.Context(), taskID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FinalizeTask godoc
+// @Summary Завершить добавление файлов
+// @Description Закрывает задачу для новых файлов и ставит ее в очередь на архивацию. Задача должна содержать хотя бы один файл. Если очередь заполнена, возвращается 429 и задача остается открытой
+// @Tags tasks
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 202 {object} dto.ResponseTask
+// @Failure 400 {object} response.BadRequestError
+// @Failure 404 {object} response.NotFoundRequestError
+// @Failure 409 {object} response.ConflictRequestError
+// @Failure 422 {object} response.ConstrainsErrorResponse
+// @Failure 429 {object} response.ServerBusyRequestError
+// @Failure 500 {object} response.InternalServerError
+// @Router /api/tasks/{id}/finalize [post]
+func (h *TaskHandler) FinalizeTask(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "task ID is required", nil)
+		return
+	}
+
+	taskResponse, err := h.usecase.Finalize(r.Context(), taskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		case errors.Is(err, repository.ErrTaskClosed):
+			response.RespondWithError(w, http.StatusConflict, "Task is already finalized", err)
+		case errors.Is(err, usecase.ErrEphemeralTask):
+			response.RespondWithError(w, http.StatusConflict, "Ephemeral tasks are only streamed", err)
+		case errors.Is(err, usecase.ErrTaskEmpty):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, "Task has no files", err)
+		case errors.Is(err, usecase.ErrServerBusy):
+			response.RespondWithError(w, http.StatusTooManyRequests, "Server is busy", err)
 		default:
 			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
 		}
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusNoContent, nil)
+	response.RespondWithJSON(w, http.StatusAccepted, taskResponse)
 }
 
 // GetTaskStatus godoc
 // @Summary Получить статус задачи
-// @Description Возвращает текущий статус задачи и ссылку на архив (если готов)
+// @Description Возвращает текущий статус задачи, прогресс загрузки файлов, ошибки и ссылку на архив (если готов)
 // @Tags tasks
 // @Produce json
 // @Param id path string true "ID задачи"
@@ -171,10 +378,10 @@ func (h *TaskHandler) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statusResponse, err := h.usecase.GetTaskStatus(taskID)
+	statusResponse, err := h.usecase.GetTaskStatus(r.Context(), taskID)
 	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), "not found"):
+		case errors.Is(err, repository.ErrTaskNotFound):
 			response.RespondWithError(w, http.StatusNotFound, "not found", err)
 		default:
 			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
@@ -184,3 +391,195 @@ func (h *TaskHandler) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 
 	response.RespondWithJSON(w, http.StatusOK, statusResponse)
 }
+
+// VerifyArchive godoc
+// @Summary Проверить целостность архива
+// @Description Перечитывает архив завершенной задачи с диска: сверяет размер и SHA-256 с сохраненными при сборке, проверяет структуру и контрольные суммы формата (CRC в ZIP, gzip и zstd), а также содержимое каждого файла с проверенным при валидации.
+// @Description Найденные повреждения перечислены в problems, ok=false. Для архивов, собранных до появления хешей, проверяются только структура и содержимое файлов
+// @Tags tasks
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} dto.VerifyResponse
+// @Failure 400 {object} response.BadRequestError
+// @Failure 404 {object} response.NotFoundRequestError
+// @Failure 409 {object} response.ConflictRequestError
+// @Failure 500 {object} response.InternalServerError
+// @Router /api/tasks/{id}/verify [post]
+func (h *TaskHandler) VerifyArchive(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "task ID is required", nil)
+		return
+	}
+
+	verifyResponse, err := h.usecase.Verify(r.Context(), taskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		case errors.Is(err, usecase.ErrArchiveNotReady):
+			response.RespondWithError(w, http.StatusConflict, "Archive is not ready yet", err)
+		case errors.Is(err, usecase.ErrEphemeralTask):
+			response.RespondWithError(w, http.StatusConflict, "Ephemeral tasks have no stored archive", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, verifyResponse)
+}
+
+// DownloadArchive godoc
+// @Summary Скачать готовый архив
+// @Description Отдает архив завершенной задачи в выбранном при создании формате. Поддерживает Range запросы для докачки и условные запросы по ETag. ETag содержит SHA-256 архива, он же передается в заголовках Digest и Repr-Digest.
+// @Description С stream=true архив не хранится: файлы задачи загружаются заново и сразу передаются в ответ (chunked). Итог передается в трейлерах X-Archive-Status (complete, partial или failed), X-Archive-Files, X-Archive-Failed и X-Archive-Errors (JSON со списком файлов, не попавших в архив или оборванных при передаче)
+// @Tags tasks
+// @Produce application/zip,application/x-tar,application/gzip,application/zstd
+// @Param id path string true "ID задачи"
+// @Param Range header string false "Диапазон байт, например bytes=0-1023"
+// @Param If-None-Match header string false "ETag ранее полученного архива"
+// @Param stream query bool false "Собрать архив на лету, не сохраняя его"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304
+// @Failure 400 {object} response.BadRequestError
+// @Failure 404 {object} response.NotFoundRequestError
+// @Failure 409 {object} response.ConflictRequestError
+// @Failure 416
+// @Failure 422 {object} response.ConstrainsErrorResponse
+// @Failure 500 {object} response.InternalServerError
+// @Router /api/tasks/{id}/archive [get]
+func (h *TaskHandler) DownloadArchive(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "task ID is required", nil)
+		return
+	}
+
+	if value := r.URL.Query().Get("stream"); value != "" {
+		stream, err := strconv.ParseBool(value)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid stream parameter", err)
+			return
+		}
+		if stream {
+			h.streamArchive(w, r, taskID)
+			return
+		}
+	}
+
+	archive, err := h.usecase.GetArchive(r.Context(), taskID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		case errors.Is(err, usecase.ErrArchiveNotReady):
+			response.RespondWithError(w, http.StatusConflict, "Archive is not ready yet", err)
+		case errors.Is(err, usecase.ErrEphemeralTask):
+			response.RespondWithError(w, http.StatusConflict, "Ephemeral tasks are only streamed, use stream=true", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	file, err := os.Open(archive.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			response.RespondWithError(w, http.StatusNotFound, "Archive file not found", err)
+			return
+		}
+		response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", archive.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archive.Name}))
+	etag := fmt.Sprintf(`"%s-%x-%x"`, taskID, info.Size(), info.ModTime().UnixNano())
+	if sum, err := hex.DecodeString(archive.SHA256); err == nil && len(sum) > 0 {
+		// Digest and its successor Repr-Digest describe the whole archive,
+		// range responses included.
+		etag = `"` + archive.SHA256 + `"`
+		digest := base64.StdEncoding.EncodeToString(sum)
+		w.Header().Set("Digest", "sha-256="+digest)
+		w.Header().Set("Repr-Digest", "sha-256=:"+digest+":")
+	}
+	w.Header().Set("ETag", etag)
+
+	// Large archives take longer than the server's write timeout to send.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to lift write deadline: %v", err)
+	}
+
+	// ServeContent takes care of Range, If-Range, If-None-Match and
+	// If-Modified-Since handling as well as HEAD requests.
+	http.ServeContent(w, r, archive.Name, info.ModTime(), file)
+}
+
+// streamArchive sends an archive built while it is downloaded. Once the body
+// has started, the outcome can only be reported in trailers.
+func (h *TaskHandler) streamArchive(w http.ResponseWriter, r *http.Request, taskID string) {
+	started := false
+	summary, err := h.usecase.StreamArchive(r.Context(), taskID, func(file usecase.ArchiveFile) io.Writer {
+		started = true
+
+		// The stream takes as long as the downloads do.
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("Failed to lift write deadline: %v", err)
+		}
+		w.Header().Set("Content-Type", file.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+		w.Header().Set("Trailer", "X-Archive-Status, X-Archive-Files, X-Archive-Failed, X-Archive-Errors")
+		w.WriteHeader(http.StatusOK)
+		return w
+	})
+	if !started {
+		switch {
+		case errors.Is(err, repository.ErrTaskNotFound):
+			response.RespondWithError(w, http.StatusNotFound, "not found", err)
+		case errors.Is(err, usecase.ErrArchiveNotReady):
+			response.RespondWithError(w, http.StatusConflict, "Archive is not ready yet", err)
+		case errors.Is(err, usecase.ErrTaskEmpty):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, "Task has no files", err)
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Internal server error", err)
+		}
+		return
+	}
+
+	status := "complete"
+	switch {
+	case err != nil:
+		log.Println(err)
+		status = "failed"
+	case len(summary.Failed) > 0:
+		status = "partial"
+	}
+	failed, err := json.Marshal(summary.Failed)
+	if err != nil {
+		log.Println(err)
+	}
+	w.Header().Set("X-Archive-Status", status)
+	w.Header().Set("X-Archive-Files", strconv.Itoa(summary.Files))
+	w.Header().Set("X-Archive-Failed", strconv.Itoa(len(summary.Failed)))
+	w.Header().Set("X-Archive-Errors", string(failed))
+}
+
+// GetPolicy godoc
+// @Summary Получить политику типов файлов
+// @Description Возвращает действующие списки разрешенных и запрещенных MIME типов и расширений
+// @Tags policy
+// @Produce json
+// @Success 200 {object} dto.FilePolicyResponse
+// @Router /api/policy [get]
+func (h *TaskHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	response.RespondWithJSON(w, http.StatusOK, h.usecase.GetPolicy())
+}
@@ -45,10 +45,22 @@ type NotFoundRequestError struct {
 	Message string `json:"message" example:"not found"`
 }
 
+// Пример для 409 Conflict
+type ConflictRequestError struct {
+	Code    int    `json:"code" example:"409"`
+	Message string `json:"message" example:"Archive is not ready yet"`
+}
+
+// Пример для 413 Request Entity Too Large
+type RequestEntityTooLargeError struct {
+	Code    int    `json:"code" example:"413"`
+	Message string `json:"message" example:"Task size limit reached"`
+}
+
 // Пример для 422 Busy
 type ConstrainsErrorResponse struct {
 	Code    int    `json:"code" example:"422"`
-	Message string `json:"message" example:"You can only upload up to 3 files per task"`
+	Message string `json:"message" example:"File limit per task reached"`
 }
 
 // Пример для 429 Busy
@@ -11,7 +11,13 @@ import (
 func RegisterRoutes(mux *http.ServeMux, taskHandler *handlers.TaskHandler) {
 	mux.Handle("/swagger/", httpSwagger.Handler(httpSwagger.URL("/swagger/doc.json")))
 	mux.Handle("POST /api/tasks", http.HandlerFunc(taskHandler.Create))
-	mux.Handle("GET /api/tasks", http.HandlerFunc(taskHandler.GetAllTasks))
+	mux.Handle("GET /api/tasks", http.HandlerFunc(taskHandler.ListTasks))
+	mux.Handle("GET /api/tasks/{id}", http.HandlerFunc(taskHandler.GetTask))
+	mux.Handle("DELETE /api/tasks/{id}", http.HandlerFunc(taskHandler.DeleteTask))
 	mux.Handle("POST /api/tasks/{id}/urls", http.HandlerFunc(taskHandler.AddURL))
+	mux.Handle("POST /api/tasks/{id}/finalize", http.HandlerFunc(taskHandler.FinalizeTask))
 	mux.Handle("GET /api/tasks/{id}/status", http.HandlerFunc(taskHandler.GetTaskStatus))
+	mux.Handle("GET /api/tasks/{id}/archive", http.HandlerFunc(taskHandler.DownloadArchive))
+	mux.Handle("POST /api/tasks/{id}/verify", http.HandlerFunc(taskHandler.VerifyArchive))
+	mux.Handle("GET /api/policy", http.HandlerFunc(taskHandler.GetPolicy))
 }
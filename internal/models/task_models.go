@@ -8,11 +8,113 @@ type Task struct {
 	ID        string
 	Name      string
 	Status    TaskStatus
-	URLs      []string
-	Errors    []string
+	Items     []TaskItem
 	ZipPath   string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Format is the archive output format; empty means ZIP.
+	Format string
+	// Ephemeral tasks are never archived on the server, only streamed.
+	Ephemeral bool
+	// Manifest adds manifest.json and SHA256SUMS to the archive.
+	Manifest bool
+	// ArchiveSize and ArchiveSHA256 describe the archive at ZipPath.
+	ArchiveSize   int64
+	ArchiveSHA256 string
+}
+
+// Archive describes a completed archive file.
+type Archive struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+// TaskItem is a file added to a task. Validation only inspects the first
+// bytes of the file; it is downloaded in full once, straight into the
+// archive.
+type TaskItem struct {
+	URL   string
+	State ItemState
+	MIME  string
+	// Size is the size announced during validation, zero if the server did
+	// not tell. It is the actual size once the item is archived.
+	Size int64
+	// SHA256 is set once the item is archived.
+	SHA256 string
+	// Validator is the strong ETag or Last-Modified date seen during
+	// validation. Archiving fails for a file no longer matching it.
+	Validator string
+	// ErrorCode and Error describe why a failed item was left out.
+	ErrorCode ItemErrorCode
+	Error     string
+	Attempts  []Attempt
+	// Filename is the entry name requested by the client, if any.
+	Filename string
+	// EntryName is the file name inside the archive, set once the item is
+	// archived.
+	EntryName string
+}
+
+type ItemState string
+
+const (
+	// ItemStatePending items are accepted but not validated yet.
+	ItemStatePending ItemState = "pending"
+	// ItemStateValidating items are being probed.
+	ItemStateValidating ItemState = "validating"
+	// ItemStateValidated items passed validation and wait for archiving.
+	ItemStateValidated ItemState = "validated"
+	// ItemStateDownloading items are being downloaded into the archive.
+	ItemStateDownloading ItemState = "downloading"
+	// ItemStateDone items are in the archive.
+	ItemStateDone   ItemState = "done"
+	ItemStateFailed ItemState = "failed"
+)
+
+// ItemErrorCode classifies why an item failed.
+type ItemErrorCode string
+
+const (
+	ItemErrorHTTPStatus     ItemErrorCode = "http_status"
+	ItemErrorNetwork        ItemErrorCode = "network"
+	ItemErrorTimeout        ItemErrorCode = "timeout"
+	ItemErrorTypeNotAllowed ItemErrorCode = "type_not_allowed"
+	ItemErrorTooLarge       ItemErrorCode = "too_large"
+	ItemErrorBlocked        ItemErrorCode = "blocked"
+	ItemErrorContentChanged ItemErrorCode = "content_changed"
+	ItemErrorCancelled      ItemErrorCode = "cancelled"
+	ItemErrorUnknown        ItemErrorCode = "unknown"
+)
+
+// Attempt is the outcome of a single download try.
+type Attempt struct {
+	At         time.Time
+	StatusCode int
+	// Error is empty for a successful attempt.
+	Error string
+	// Transient marks failures that were worth retrying.
+	Transient bool
+}
+
+// Size returns the total size of the task's files in bytes.
+func (t *Task) Size() int64 {
+	var size int64
+	for _, item := range t.Items {
+		size += item.Size
+	}
+	return size
+}
+
+// CountItems returns the number of items in the given state.
+func (t *Task) CountItems(state ItemState) int {
+	n := 0
+	for _, item := range t.Items {
+		if item.State == state {
+			n++
+		}
+	}
+	return n
 }
 
 type TaskStatus string
@@ -20,6 +122,19 @@ type TaskStatus string
 const (
 	StatusCreated   TaskStatus = "Created"
 	StatusInProcess TaskStatus = "In process"
+	StatusQueued    TaskStatus = "Queued"
+	StatusArchiving TaskStatus = "Archiving"
 	StatusCompleted TaskStatus = "Completed"
 	StatusFailed    TaskStatus = "Failed"
+	StatusCancelled TaskStatus = "Cancelled"
 )
+
+// IsTerminal reports whether a task in this status will not change anymore.
+func (s TaskStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// AcceptsItems reports whether files may still be added to a task.
+func (s TaskStatus) AcceptsItems() bool {
+	return s == StatusCreated || s == StatusInProcess
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Policy decides which files may be added to a task. Types are matched
+// exactly ("image/jpeg") or by family ("image/*"); extensions include the
+// leading dot. Deny rules win over allow rules, and an empty allow list
+// allows everything that is not denied.
+type Policy struct {
+	AllowTypes      []string `json:"allow_types"`
+	DenyTypes       []string `json:"deny_types"`
+	AllowExtensions []string `json:"allow_extensions"`
+	DenyExtensions  []string `json:"deny_extensions"`
+}
+
+// RejectedError is returned by Check when a file violates the policy.
+type RejectedError struct {
+	// MIME is the detected content type.
+	MIME string
+	// Extension is the extension the decision was based on, if any.
+	Extension string
+	Reason    string
+}
+
+func (e *RejectedError) Error() string {
+	if e.Extension != "" {
+		return fmt.Sprintf("file type %s (%s) is not allowed: %s", e.MIME, e.Extension, e.Reason)
+	}
+	return fmt.Sprintf("file type %s is not allowed: %s", e.MIME, e.Reason)
+}
+
+// New normalizes the lists so that matching is case-insensitive.
+func New(allowTypes, denyTypes, allowExt, denyExt []string) Policy {
+	return Policy{
+		AllowTypes:      normalize(allowTypes, strings.ToLower),
+		DenyTypes:       normalize(denyTypes, strings.ToLower),
+		AllowExtensions: normalize(allowExt, NormalizeExtension),
+		DenyExtensions:  normalize(denyExt, NormalizeExtension),
+	}
+}
+
+// Validate reports malformed entries.
+func (p Policy) Validate() error {
+	for _, t := range append(append([]string{}, p.AllowTypes...), p.DenyTypes...) {
+		major, minor, ok := strings.Cut(t, "/")
+		if !ok || major == "" || minor == "" || major == "*" {
+			return fmt.Errorf("invalid MIME type pattern %q", t)
+		}
+	}
+	for _, ext := range append(append([]string{}, p.AllowExtensions...), p.DenyExtensions...) {
+		if len(ext) < 2 || strings.ContainsAny(ext[1:], "./") {
+			return fmt.Errorf("invalid extension %q", ext)
+		}
+	}
+	return nil
+}
+
+// Check validates a file by its detected MIME type, the extension matching
+// that type and the URL it was fetched from. The URL is controlled by the
+// source, so its extension can only get a file denied; the allow list is
+// matched against the detected extension alone.
+func (p Policy) Check(detectedMIME, detectedExt, rawURL string) error {
+	mimeType := BaseType(detectedMIME)
+	ext := NormalizeExtension(detectedExt)
+
+	if matchType(p.DenyTypes, mimeType) {
+		return &RejectedError{MIME: mimeType, Reason: "type is denied"}
+	}
+	for _, e := range []string{ext, NormalizeExtension(path.Ext(urlPath(rawURL)))} {
+		if e != "" && contains(p.DenyExtensions, e) {
+			return &RejectedError{MIME: mimeType, Extension: e, Reason: "extension is denied"}
+		}
+	}
+
+	if len(p.AllowTypes) > 0 && !matchType(p.AllowTypes, mimeType) {
+		return &RejectedError{MIME: mimeType, Reason: "type is not in the allow list"}
+	}
+	if len(p.AllowExtensions) > 0 && !contains(p.AllowExtensions, ext) {
+		return &RejectedError{MIME: mimeType, Extension: ext, Reason: "extension is not in the allow list"}
+	}
+
+	return nil
+}
+
+// CheckName validates the name a file will be stored under, so that a name
+// chosen by the client cannot give a file an extension the policy forbids.
+func (p Policy) CheckName(detectedMIME, name string) error {
+	mimeType := BaseType(detectedMIME)
+	ext := NormalizeExtension(path.Ext(name))
+
+	if ext != "" && contains(p.DenyExtensions, ext) {
+		return &RejectedError{MIME: mimeType, Extension: ext, Reason: "extension is denied"}
+	}
+	if len(p.AllowExtensions) > 0 && !contains(p.AllowExtensions, ext) {
+		return &RejectedError{MIME: mimeType, Extension: ext, Reason: "extension is not in the allow list"}
+	}
+	return nil
+}
+
+// BaseType strips parameters such as charset from a MIME type.
+func BaseType(mimeType string) string {
+	if t, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return t
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// NormalizeExtension lowercases an extension and adds the leading dot.
+func NormalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func urlPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+func matchType(patterns []string, mimeType string) bool {
+	family, _, _ := strings.Cut(mimeType, "/")
+	for _, pattern := range patterns {
+		if pattern == mimeType || pattern == family+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(list []string, fn func(string) string) []string {
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if item = fn(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		mime    string
+		ext     string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:   "empty policy allows everything",
+			policy: New(nil, nil, nil, nil),
+			mime:   "application/x-msdownload",
+			ext:    ".exe",
+			url:    "http://host/setup.exe",
+		},
+		{
+			name:   "allowed type",
+			policy: New([]string{"application/pdf"}, nil, nil, nil),
+			mime:   "application/pdf",
+			ext:    ".pdf",
+			url:    "http://host/a.pdf",
+		},
+		{
+			name:   "type parameters are ignored",
+			policy: New([]string{"text/plain"}, nil, nil, nil),
+			mime:   "text/plain; charset=utf-8",
+			ext:    ".txt",
+			url:    "http://host/a.txt",
+		},
+		{
+			name:   "type family",
+			policy: New([]string{"IMAGE/*"}, nil, nil, nil),
+			mime:   "image/png",
+			ext:    ".png",
+			url:    "http://host/a.png",
+		},
+		{
+			name:    "type not in allow list",
+			policy:  New([]string{"application/pdf"}, nil, nil, nil),
+			mime:    "image/png",
+			ext:     ".png",
+			url:     "http://host/a.pdf",
+			wantErr: true,
+		},
+		{
+			name:    "deny wins over allow",
+			policy:  New([]string{"image/*"}, []string{"image/svg+xml"}, nil, nil),
+			mime:    "image/svg+xml",
+			ext:     ".svg",
+			url:     "http://host/a.svg",
+			wantErr: true,
+		},
+		{
+			name:    "denied URL extension",
+			policy:  New(nil, nil, nil, []string{"exe"}),
+			mime:    "application/pdf",
+			ext:     ".pdf",
+			url:     "http://host/setup.EXE?download=1",
+			wantErr: true,
+		},
+		{
+			name:    "denied detected extension",
+			policy:  New(nil, nil, nil, []string{".exe"}),
+			mime:    "application/x-msdownload",
+			ext:     ".exe",
+			url:     "http://host/report.pdf",
+			wantErr: true,
+		},
+		{
+			name:   "allowed detected extension",
+			policy: New(nil, nil, []string{".pdf"}, nil),
+			mime:   "application/pdf",
+			ext:    ".pdf",
+			url:    "http://host/download?id=1",
+		},
+		{
+			name:    "allowed URL extension does not allow other content",
+			policy:  New(nil, nil, []string{".pdf"}, nil),
+			mime:    "application/x-msdownload",
+			ext:     ".exe",
+			url:     "http://host/evil.pdf",
+			wantErr: true,
+		},
+		{
+			name:    "unknown content with allowed URL extension",
+			policy:  New(nil, nil, []string{".pdf"}, nil),
+			mime:    "application/octet-stream",
+			ext:     "",
+			url:     "http://host/evil.pdf",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.mime, tt.ext, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var rejected *RejectedError
+			if err != nil && !errors.As(err, &rejected) {
+				t.Errorf("Check() error = %T, want *RejectedError", err)
+			}
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	p := New(nil, nil, []string{".pdf", ".jpg"}, []string{".exe"})
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"report.pdf", false},
+		{"Photo.JPG", false},
+		{"report.exe", true},
+		{"report.txt", true},
+		{"report", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CheckName("application/pdf", tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"valid", New([]string{"image/*", "application/pdf"}, nil, []string{"pdf"}, nil), false},
+		{"type without subtype", New([]string{"image"}, nil, nil, nil), true},
+		{"any type", New([]string{"*/*"}, nil, nil, nil), true},
+		{"extension with dot", New(nil, nil, nil, []string{".tar.gz"}), true},
+		{"extension with slash", New(nil, nil, []string{"a/b"}, nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// latestSchema is the schema version NewBoltTaskRepository migrates to.
+const latestSchema = "4"
+
+func TestBoltMigrations(t *testing.T) {
+	type wantItem struct {
+		url   string
+		state models.ItemState
+		code  models.ItemErrorCode
+		err   string
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		doc     string
+		want    []wantItem
+	}{
+		{
+			name:    "v1 URLs and errors",
+			version: "1",
+			doc: `{"ID":"t","Status":"In process","URLs":["https://e.com/a.pdf","https://e.com/b.pdf"],
+				"Errors":["https://e.com/b.pdf: status 404"],"CreatedAt":"2025-07-30T10:00:00Z"}`,
+			want: []wantItem{
+				{"https://e.com/a.pdf", models.ItemStatePending, "", ""},
+				{"https://e.com/b.pdf", models.ItemStateFailed, models.ItemErrorUnknown, "status 404"},
+			},
+		},
+		{
+			name:    "v1 without URLs",
+			version: "1",
+			doc:     `{"ID":"t","Status":"Created","CreatedAt":"2025-07-30T10:00:00Z"}`,
+			want:    []wantItem{},
+		},
+		{
+			name:    "v2 completed task",
+			version: "2",
+			doc: `{"ID":"t","Status":"Completed","Items":[{"URL":"https://e.com/a.pdf","SHA256":"ab"},{"URL":"https://e.com/b.pdf"}],
+				"Errors":["https://e.com/b.pdf: timeout"],"CreatedAt":"2025-07-30T10:00:00Z"}`,
+			want: []wantItem{
+				{"https://e.com/a.pdf", models.ItemStateDone, "", ""},
+				{"https://e.com/b.pdf", models.ItemStateFailed, models.ItemErrorUnknown, "timeout"},
+			},
+		},
+		{
+			name:    "v2 queued task",
+			version: "2",
+			doc:     `{"ID":"t","Status":"Queued","Items":[{"URL":"https://e.com/a.pdf","SHA256":"ab"}],"CreatedAt":"2025-07-30T10:00:00Z"}`,
+			want: []wantItem{
+				{"https://e.com/a.pdf", models.ItemStateValidated, "", ""},
+			},
+		},
+		{
+			name:    "v3 unfinished task",
+			version: "3",
+			doc: `{"ID":"t","Status":"In process","Items":[{"URL":"https://e.com/a.pdf","State":"done"},
+				{"URL":"https://e.com/b.pdf","State":"downloading"},{"URL":"https://e.com/c.pdf","State":"failed","ErrorCode":"timeout"}],
+				"CreatedAt":"2025-07-30T10:00:00Z"}`,
+			want: []wantItem{
+				{"https://e.com/a.pdf", models.ItemStateValidated, "", ""},
+				{"https://e.com/b.pdf", models.ItemStatePending, "", ""},
+				{"https://e.com/c.pdf", models.ItemStateFailed, models.ItemErrorTimeout, ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := seedBolt(t, tt.version, tt.doc)
+
+			repo, err := repository.NewBoltTaskRepository(path, 10, 0)
+			if err != nil {
+				t.Fatalf("NewBoltTaskRepository() error = %v", err)
+			}
+			task, err := repo.GetTaskByID(context.Background(), "t")
+			repo.Close()
+			if err != nil {
+				t.Fatalf("GetTaskByID() error = %v", err)
+			}
+
+			if len(task.Items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d", len(task.Items), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := task.Items[i]
+				if got.URL != want.url || got.State != want.state || got.ErrorCode != want.code || got.Error != want.err {
+					t.Errorf("item %d = %s %q %q %q, want %s %q %q %q", i,
+						got.URL, got.State, got.ErrorCode, got.Error, want.url, want.state, want.code, want.err)
+				}
+			}
+			if version := schemaVersion(t, path); version != latestSchema {
+				t.Errorf("schema version = %s, want %s", version, latestSchema)
+			}
+		})
+	}
+}
+
+func TestBoltRefusesNewerSchema(t *testing.T) {
+	path := seedBolt(t, "99", `{"ID":"t","Status":"Created"}`)
+
+	_, err := repository.NewBoltTaskRepository(path, 10, 0)
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("NewBoltTaskRepository() error = %v, want a newer schema error", err)
+	}
+	if version := schemaVersion(t, path); version != "99" {
+		t.Errorf("schema version = %s after a refused migration, want 99", version)
+	}
+}
+
+// seedBolt writes a database at schema version with a single raw task
+// document stored under the ID "t".
+func seedBolt(t *testing.T, version, doc string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucket([]byte("meta"))
+		if err != nil {
+			return err
+		}
+		if err := meta.Put([]byte("schema_version"), []byte(version)); err != nil {
+			return err
+		}
+		tasks, err := tx.CreateBucket([]byte("tasks"))
+		if err != nil {
+			return err
+		}
+		return tasks.Put([]byte("t"), []byte(doc))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func schemaVersion(t *testing.T, path string) string {
+	t.Helper()
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var version string
+	err = db.View(func(tx *bolt.Tx) error {
+		version = string(tx.Bucket([]byte("meta")).Get([]byte("schema_version")))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
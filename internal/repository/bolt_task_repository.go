@@ -0,0 +1,437 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	tasksBucket = []byte("tasks")
+	metaBucket  = []byte("meta")
+
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migration upgrades the database from version-1 to version. Migrations run
+// inside a single write transaction, so a failed migration leaves the file
+// untouched.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *bolt.Tx) error
+}
+
+// migrations must be kept in ascending order. Never edit a released
+// migration, append a new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create tasks bucket",
+		up: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(tasksBucket)
+			return err
+		},
+	},
+	{
+		version: 2,
+		name:    "replace task URLs with items",
+		up: func(tx *bolt.Tx) error {
+			return rewriteTasks(tx, func(doc map[string]json.RawMessage) error {
+				var urls []string
+				if raw, ok := doc["URLs"]; ok {
+					if err := json.Unmarshal(raw, &urls); err != nil {
+						return err
+					}
+				}
+
+				items := make([]map[string]string, 0, len(urls))
+				for _, url := range urls {
+					items = append(items, map[string]string{"URL": url})
+				}
+
+				raw, err := json.Marshal(items)
+				if err != nil {
+					return err
+				}
+				doc["Items"] = raw
+				delete(doc, "URLs")
+				return nil
+			})
+		},
+	},
+	{
+		version: 3,
+		name:    "replace task errors with item states",
+		up: func(tx *bolt.Tx) error {
+			return rewriteTasks(tx, func(doc map[string]json.RawMessage) error {
+				var taskErrors []string
+				if raw, ok := doc["Errors"]; ok {
+					if err := json.Unmarshal(raw, &taskErrors); err != nil {
+						return err
+					}
+				}
+
+				var items []map[string]json.RawMessage
+				if raw, ok := doc["Items"]; ok {
+					if err := json.Unmarshal(raw, &items); err != nil {
+						return err
+					}
+				}
+
+				for _, item := range items {
+					var url, sha string
+					json.Unmarshal(item["URL"], &url)
+					json.Unmarshal(item["SHA256"], &sha)
+
+					// Errors were stored as "<url>: <message>".
+					state, code, message := "pending", "", ""
+					if sha != "" {
+						state = "done"
+					}
+					for _, e := range taskErrors {
+						if msg, ok := strings.CutPrefix(e, url+": "); ok {
+							state, code, message = "failed", "unknown", msg
+							break
+						}
+					}
+
+					for key, value := range map[string]string{"State": state, "ErrorCode": code, "Error": message} {
+						raw, err := json.Marshal(value)
+						if err != nil {
+							return err
+						}
+						item[key] = raw
+					}
+				}
+
+				if items == nil {
+					items = []map[string]json.RawMessage{}
+				}
+				raw, err := json.Marshal(items)
+				if err != nil {
+					return err
+				}
+				doc["Items"] = raw
+				delete(doc, "Errors")
+				return nil
+			})
+		},
+	},
+	{
+		version: 4,
+		name:    "separate validated items from archived ones",
+		up: func(tx *bolt.Tx) error {
+			return rewriteTasks(tx, func(doc map[string]json.RawMessage) error {
+				var status string
+				json.Unmarshal(doc["Status"], &status)
+				switch status {
+				case "Completed", "Failed", "Cancelled":
+					return nil
+				}
+
+				var items []map[string]json.RawMessage
+				if raw, ok := doc["Items"]; ok {
+					if err := json.Unmarshal(raw, &items); err != nil {
+						return err
+					}
+				}
+
+				// Unfinished tasks have not been archived yet, so their
+				// items were done or downloading only as far as validation
+				// goes.
+				for _, item := range items {
+					var state string
+					json.Unmarshal(item["State"], &state)
+					switch state {
+					case "done":
+						state = "validated"
+					case "downloading":
+						state = "pending"
+					default:
+						continue
+					}
+					raw, err := json.Marshal(state)
+					if err != nil {
+						return err
+					}
+					item["State"] = raw
+				}
+
+				if items == nil {
+					return nil
+				}
+				raw, err := json.Marshal(items)
+				if err != nil {
+					return err
+				}
+				doc["Items"] = raw
+				return nil
+			})
+		},
+	},
+}
+
+// rewriteTasks applies fn to the raw JSON document of every stored task.
+// Migrations work on raw documents so they do not depend on the current
+// shape of models.Task.
+func rewriteTasks(tx *bolt.Tx, fn func(doc map[string]json.RawMessage) error) error {
+	bucket := tx.Bucket(tasksBucket)
+
+	updated := map[string][]byte{}
+	err := bucket.ForEach(func(k, v []byte) error {
+		doc := map[string]json.RawMessage{}
+		if err := json.Unmarshal(v, &doc); err != nil {
+			return fmt.Errorf("failed to decode task %s: %w", k, err)
+		}
+		if err := fn(doc); err != nil {
+			return fmt.Errorf("failed to migrate task %s: %w", k, err)
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		updated[string(k)] = raw
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keys must not be modified while iterating with ForEach.
+	for k, v := range updated {
+		if err := bucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BoltTaskRepository keeps tasks in an embedded bbolt database so they
+// survive restarts. Tasks are stored as JSON documents keyed by ID.
+type BoltTaskRepository struct {
+	db       *bolt.DB
+	maxURLs  int
+	maxBytes int64
+}
+
+// NewBoltTaskRepository opens (or creates) the database at path and migrates
+// it to the latest schema. Tasks accept at most maxURLs files and maxBytes
+// bytes (zero for no limit).
+func NewBoltTaskRepository(path string, maxURLs int, maxBytes int64) (*BoltTaskRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltTaskRepository{db: db, maxURLs: maxURLs, maxBytes: maxBytes}, nil
+}
+
+func migrate(db *bolt.DB) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		current := 0
+		if raw := meta.Get(schemaVersionKey); raw != nil {
+			v, err := strconv.Atoi(string(raw))
+			if err != nil {
+				return fmt.Errorf("invalid schema version %q: %w", raw, err)
+			}
+			current = v
+		}
+
+		latest := migrations[len(migrations)-1].version
+		if current > latest {
+			return fmt.Errorf("database schema version %d is newer than supported version %d", current, latest)
+		}
+
+		for _, m := range migrations {
+			if m.version <= current {
+				continue
+			}
+			if err := m.up(tx); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+			}
+			current = m.version
+		}
+
+		return meta.Put(schemaVersionKey, []byte(strconv.Itoa(current)))
+	})
+}
+
+func (r *BoltTaskRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *BoltTaskRepository) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	newTask := newTask(task)
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		return putTask(tx, newTask)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTask, nil
+}
+
+func (r *BoltTaskRepository) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks := []*models.Task{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(tasksBucket).ForEach(func(_, v []byte) error {
+			task := &models.Task{}
+			if err := json.Unmarshal(v, task); err != nil {
+				return fmt.Errorf("failed to decode task: %w", err)
+			}
+			tasks = append(tasks, task)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	return tasks, nil
+}
+
+func (r *BoltTaskRepository) AddItem(ctx context.Context, taskID string, item models.TaskItem) (int, error) {
+	var index int
+	err := r.modify(ctx, taskID, func(task *models.Task) error {
+		var err error
+		index, err = addItem(task, item, r.maxURLs, r.maxBytes)
+		return err
+	})
+	return index, err
+}
+
+func (r *BoltTaskRepository) UpdateItem(ctx context.Context, taskID string, index int, item models.TaskItem) error {
+	return r.modify(ctx, taskID, func(task *models.Task) error {
+		return updateItem(task, index, item)
+	})
+}
+
+func (r *BoltTaskRepository) GetTaskByID(ctx context.Context, id string) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var task *models.Task
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		task, err = getTask(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *BoltTaskRepository) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus) error {
+	return r.modify(ctx, id, func(task *models.Task) error {
+		task.Status = status
+		task.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *BoltTaskRepository) UpdateTask(
+	ctx context.Context,
+	taskID string,
+	archive models.Archive,
+	status models.TaskStatus,
+) error {
+	return r.modify(ctx, taskID, func(task *models.Task) error {
+		task.ZipPath = archive.Path
+		task.ArchiveSize = archive.Size
+		task.ArchiveSHA256 = archive.SHA256
+		task.Status = status
+		task.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *BoltTaskRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tasksBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrTaskNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+// modify loads a task, applies fn and writes the result back in a single
+// transaction.
+func (r *BoltTaskRepository) modify(ctx context.Context, id string, fn func(task *models.Task) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		task, err := getTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
+		}
+		return putTask(tx, task)
+	})
+}
+
+func getTask(tx *bolt.Tx, id string) (*models.Task, error) {
+	raw := tx.Bucket(tasksBucket).Get([]byte(id))
+	if raw == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	task := &models.Task{}
+	if err := json.Unmarshal(raw, task); err != nil {
+		return nil, fmt.Errorf("failed to decode task %s: %w", id, err)
+	}
+	return task, nil
+}
+
+func putTask(tx *bolt.Tx, task *models.Task) error {
+	raw, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task %s: %w", task.ID, err)
+	}
+	return tx.Bucket(tasksBucket).Put([]byte(task.ID), raw)
+}
@@ -0,0 +1,547 @@
+// Package storetest provides the contract test suite every
+// repository.TaskStore implementation must pass.
+//
+// Implementations wire it up from their own tests:
+//
+//	func TestBoltTaskRepository(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) repository.TaskStore {
+//			path := filepath.Join(t.TempDir(), "tasks.db")
+//			repo, err := repository.NewBoltTaskRepository(path, storetest.MaxURLs, storetest.MaxBytes)
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			t.Cleanup(func() { repo.Close() })
+//			return repo
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+)
+
+// Per-task limits the stores under test must be configured with.
+const (
+	MaxURLs  = 3
+	MaxBytes = 1000
+)
+
+// Factory returns a new, empty store accepting MaxURLs files and MaxBytes
+// bytes per task.
+// It is called once per subtest.
+type Factory func(t *testing.T) repository.TaskStore
+
+// Run executes the whole contract suite against stores created by newStore.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store repository.TaskStore)
+	}{
+		{"CreateAssignsDefaults", testCreateAssignsDefaults},
+		{"CreateKeepsOptions", testCreateKeepsOptions},
+		{"GetTaskByIDNotFound", testGetTaskByIDNotFound},
+		{"AddItem", testAddItem},
+		{"AddItemNotFound", testAddItemNotFound},
+		{"AddItemLimit", testAddItemLimit},
+		{"AddItemLimitSkipsFailed", testAddItemLimitSkipsFailed},
+		{"AddItemSizeLimit", testAddItemSizeLimit},
+		{"AddItemClosed", testAddItemClosed},
+		{"UpdateItem", testUpdateItem},
+		{"UpdateItemNotFound", testUpdateItemNotFound},
+		{"UpdateTask", testUpdateTask},
+		{"UpdateTaskNotFound", testUpdateTaskNotFound},
+		{"UpdateTaskStatus", testUpdateTaskStatus},
+		{"UpdateTaskStatusNotFound", testUpdateTaskStatusNotFound},
+		{"GetAllTasks", testGetAllTasks},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"ReturnsCopies", testReturnsCopies},
+		{"CanceledContext", testCanceledContext},
+		{"ConcurrentAddItem", testConcurrentAddItem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func mustCreate(t *testing.T, store repository.TaskStore, name string) *models.Task {
+	t.Helper()
+
+	task, err := store.Create(context.Background(), &models.Task{Name: name})
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", name, err)
+	}
+	return task
+}
+
+func mustGet(t *testing.T, store repository.TaskStore, id string) *models.Task {
+	t.Helper()
+
+	task, err := store.GetTaskByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetTaskByID(%q) error = %v", id, err)
+	}
+	return task
+}
+
+func item(url string) models.TaskItem {
+	return models.TaskItem{
+		URL:       url,
+		State:     models.ItemStateDone,
+		MIME:      "application/pdf",
+		Size:      42,
+		SHA256:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		Validator: `"33a64df5"`,
+		Attempts: []models.Attempt{
+			{At: time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC), StatusCode: 503, Error: "server returned 503", Transient: true},
+			{At: time.Date(2025, 7, 30, 12, 0, 1, 0, time.UTC), StatusCode: 200},
+		},
+		Filename: "report.pdf",
+	}
+}
+
+func testCreateAssignsDefaults(t *testing.T, store repository.TaskStore) {
+	task := mustCreate(t, store, "docs")
+
+	if task.ID == "" {
+		t.Error("Create() returned a task without ID")
+	}
+	if task.Name != "docs" {
+		t.Errorf("Name = %q, want %q", task.Name, "docs")
+	}
+	if task.Status != models.StatusCreated {
+		t.Errorf("Status = %q, want %q", task.Status, models.StatusCreated)
+	}
+	if len(task.Items) != 0 {
+		t.Errorf("new task has items %v, want none", task.Items)
+	}
+	if task.CreatedAt.IsZero() || task.UpdatedAt.IsZero() {
+		t.Error("Create() did not set timestamps")
+	}
+
+	other := mustCreate(t, store, "docs")
+	if other.ID == task.ID {
+		t.Errorf("two tasks share ID %q", task.ID)
+	}
+
+	got := mustGet(t, store, task.ID)
+	if got.ID != task.ID || got.Name != task.Name || got.Status != task.Status {
+		t.Errorf("GetTaskByID() = %+v, want %+v", got, task)
+	}
+}
+
+func testCreateKeepsOptions(t *testing.T, store repository.TaskStore) {
+	task, err := store.Create(context.Background(), &models.Task{Name: "docs", Format: "tar.gz", Ephemeral: true, Manifest: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if task.Format != "tar.gz" || !task.Ephemeral || !task.Manifest {
+		t.Errorf("Create() Format = %q, Ephemeral = %v, Manifest = %v, want %q, true, true", task.Format, task.Ephemeral, task.Manifest, "tar.gz")
+	}
+	if got := mustGet(t, store, task.ID); got.Format != "tar.gz" || !got.Ephemeral || !got.Manifest {
+		t.Errorf("stored Format = %q, Ephemeral = %v, Manifest = %v, want %q, true, true", got.Format, got.Ephemeral, got.Manifest, "tar.gz")
+	}
+}
+
+func testGetTaskByIDNotFound(t *testing.T, store repository.TaskStore) {
+	_, err := store.GetTaskByID(context.Background(), "missing")
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("GetTaskByID() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testAddItem(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+
+	index, err := store.AddItem(ctx, task.ID, item("http://example.com/a.pdf"))
+	if err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	if index != 0 {
+		t.Errorf("AddItem() index = %d, want 0", index)
+	}
+	if index, err := store.AddItem(ctx, task.ID, item("http://example.com/b.pdf")); err != nil || index != 1 {
+		t.Errorf("second AddItem() = %d, %v, want 1, nil", index, err)
+	}
+
+	got := mustGet(t, store, task.ID)
+	want := []models.TaskItem{item("http://example.com/a.pdf"), item("http://example.com/b.pdf")}
+	if !reflect.DeepEqual(got.Items, want) {
+		t.Errorf("Items = %+v, want %+v", got.Items, want)
+	}
+	if got.Status != models.StatusInProcess {
+		t.Errorf("Status = %q, want %q", got.Status, models.StatusInProcess)
+	}
+	if got.UpdatedAt.Before(task.UpdatedAt) {
+		t.Error("AddItem() did not advance UpdatedAt")
+	}
+}
+
+func testAddItemNotFound(t *testing.T, store repository.TaskStore) {
+	_, err := store.AddItem(context.Background(), "missing", item("http://example.com/a.pdf"))
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("AddItem() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testAddItemLimit(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+
+	for i := 0; i < MaxURLs; i++ {
+		if _, err := store.AddItem(ctx, task.ID, item(fmt.Sprintf("http://example.com/%d.pdf", i))); err != nil {
+			t.Fatalf("AddItem() #%d error = %v", i, err)
+		}
+	}
+
+	_, err := store.AddItem(ctx, task.ID, item("http://example.com/extra.pdf"))
+	if !errors.Is(err, repository.ErrURLLimitReached) {
+		t.Errorf("AddItem() over the limit error = %v, want ErrURLLimitReached", err)
+	}
+
+	if got := mustGet(t, store, task.ID); len(got.Items) != MaxURLs {
+		t.Errorf("len(Items) = %d, want %d", len(got.Items), MaxURLs)
+	}
+}
+
+func testAddItemLimitSkipsFailed(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+
+	failed := item("http://example.com/failed.pdf")
+	failed.State = models.ItemStateFailed
+	if _, err := store.AddItem(ctx, task.ID, failed); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	for i := 0; i < MaxURLs; i++ {
+		if _, err := store.AddItem(ctx, task.ID, item(fmt.Sprintf("http://example.com/%d.pdf", i))); err != nil {
+			t.Fatalf("AddItem() #%d next to a failed item error = %v", i, err)
+		}
+	}
+
+	_, err := store.AddItem(ctx, task.ID, item("http://example.com/extra.pdf"))
+	if !errors.Is(err, repository.ErrURLLimitReached) {
+		t.Errorf("AddItem() over the limit error = %v, want ErrURLLimitReached", err)
+	}
+}
+
+func testAddItemSizeLimit(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+
+	sized := func(url string, size int64) models.TaskItem {
+		it := item(url)
+		it.Size = size
+		return it
+	}
+
+	if _, err := store.AddItem(ctx, task.ID, sized("http://example.com/a.pdf", MaxBytes/2)); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+
+	_, err := store.AddItem(ctx, task.ID, sized("http://example.com/b.pdf", MaxBytes/2+1))
+	if !errors.Is(err, repository.ErrSizeLimitReached) {
+		t.Errorf("AddItem() over the size limit error = %v, want ErrSizeLimitReached", err)
+	}
+
+	if _, err := store.AddItem(ctx, task.ID, sized("http://example.com/c.pdf", MaxBytes/2)); err != nil {
+		t.Errorf("AddItem() up to the size limit error = %v", err)
+	}
+
+	if got := mustGet(t, store, task.ID); len(got.Items) != 2 || got.Size() != MaxBytes {
+		t.Errorf("stored %d items of %d bytes, want 2 items of %d bytes", len(got.Items), got.Size(), MaxBytes)
+	}
+}
+
+func testAddItemClosed(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+
+	for _, status := range []models.TaskStatus{
+		models.StatusQueued,
+		models.StatusArchiving,
+		models.StatusCompleted,
+		models.StatusFailed,
+		models.StatusCancelled,
+	} {
+		task := mustCreate(t, store, "docs")
+		if err := store.UpdateTaskStatus(ctx, task.ID, status); err != nil {
+			t.Fatalf("UpdateTaskStatus() error = %v", err)
+		}
+
+		_, err := store.AddItem(ctx, task.ID, item("http://example.com/a.pdf"))
+		if !errors.Is(err, repository.ErrTaskClosed) {
+			t.Errorf("AddItem() to a %q task error = %v, want ErrTaskClosed", status, err)
+		}
+	}
+}
+
+func testUpdateItem(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+	for _, url := range []string{"http://example.com/a.pdf", "http://example.com/b.pdf"} {
+		if _, err := store.AddItem(ctx, task.ID, item(url)); err != nil {
+			t.Fatalf("AddItem() error = %v", err)
+		}
+	}
+
+	updated := item("http://example.com/b.pdf")
+	updated.State = models.ItemStateFailed
+	updated.ErrorCode = models.ItemErrorHTTPStatus
+	updated.Error = "server returned 404"
+	updated.Validator = `"5d8c72a5"`
+	updated.Attempts = append(updated.Attempts, models.Attempt{
+		At:         time.Date(2025, 7, 30, 13, 0, 0, 0, time.UTC),
+		StatusCode: 404,
+		Error:      "server returned 404",
+	})
+	if err := store.UpdateItem(ctx, task.ID, 1, updated); err != nil {
+		t.Fatalf("UpdateItem() error = %v", err)
+	}
+
+	got := mustGet(t, store, task.ID)
+	if len(got.Items) != 2 {
+		t.Fatalf("len(Items) = %d, want 2", len(got.Items))
+	}
+	if !reflect.DeepEqual(got.Items[0], item("http://example.com/a.pdf")) {
+		t.Errorf("Items[0] = %+v, want it unchanged", got.Items[0])
+	}
+	if !reflect.DeepEqual(got.Items[1], updated) {
+		t.Errorf("Items[1] = %+v, want %+v", got.Items[1], updated)
+	}
+}
+
+func testUpdateItemNotFound(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+
+	err := store.UpdateItem(ctx, "missing", 0, item("http://example.com/a.pdf"))
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("UpdateItem() on a missing task error = %v, want ErrTaskNotFound", err)
+	}
+
+	task := mustCreate(t, store, "docs")
+	err = store.UpdateItem(ctx, task.ID, 0, item("http://example.com/a.pdf"))
+	if !errors.Is(err, repository.ErrItemNotFound) {
+		t.Errorf("UpdateItem() on a missing item error = %v, want ErrItemNotFound", err)
+	}
+}
+
+func testUpdateTask(t *testing.T, store repository.TaskStore) {
+	task := mustCreate(t, store, "docs")
+
+	archive := models.Archive{
+		Path:   "storage/x.zip",
+		Size:   1234,
+		SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	}
+	err := store.UpdateTask(context.Background(), task.ID, archive, models.StatusCompleted)
+	if err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	got := mustGet(t, store, task.ID)
+	if got.ZipPath != archive.Path {
+		t.Errorf("ZipPath = %q, want %q", got.ZipPath, archive.Path)
+	}
+	if got.ArchiveSize != archive.Size || got.ArchiveSHA256 != archive.SHA256 {
+		t.Errorf("archive size %d, sha256 %q, want %d, %q", got.ArchiveSize, got.ArchiveSHA256, archive.Size, archive.SHA256)
+	}
+	if got.Status != models.StatusCompleted {
+		t.Errorf("Status = %q, want %q", got.Status, models.StatusCompleted)
+	}
+}
+
+func testUpdateTaskNotFound(t *testing.T, store repository.TaskStore) {
+	err := store.UpdateTask(context.Background(), "missing", models.Archive{}, models.StatusFailed)
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("UpdateTask() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testUpdateTaskStatus(t *testing.T, store repository.TaskStore) {
+	task := mustCreate(t, store, "docs")
+	// Retention counts from UpdatedAt, so it must move past the creation.
+	time.Sleep(10 * time.Millisecond)
+
+	if err := store.UpdateTaskStatus(context.Background(), task.ID, models.StatusFailed); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+
+	got := mustGet(t, store, task.ID)
+	if got.Status != models.StatusFailed {
+		t.Errorf("Status = %q, want %q", got.Status, models.StatusFailed)
+	}
+	if !got.UpdatedAt.After(task.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want after %v", got.UpdatedAt, task.UpdatedAt)
+	}
+}
+
+func testUpdateTaskStatusNotFound(t *testing.T, store repository.TaskStore) {
+	err := store.UpdateTaskStatus(context.Background(), "missing", models.StatusFailed)
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("UpdateTaskStatus() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testGetAllTasks(t *testing.T, store repository.TaskStore) {
+	tasks, err := store.GetAllTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("GetAllTasks() on an empty store returned %d tasks", len(tasks))
+	}
+
+	want := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		want[mustCreate(t, store, fmt.Sprintf("task-%d", i)).ID] = true
+	}
+
+	tasks, err = store.GetAllTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(tasks) != len(want) {
+		t.Fatalf("GetAllTasks() returned %d tasks, want %d", len(tasks), len(want))
+	}
+	for _, task := range tasks {
+		if !want[task.ID] {
+			t.Errorf("GetAllTasks() returned unexpected task %q", task.ID)
+		}
+	}
+}
+
+func testDelete(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+	other := mustCreate(t, store, "other")
+
+	if err := store.Delete(ctx, task.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := store.GetTaskByID(ctx, task.ID); !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("GetTaskByID() after Delete() error = %v, want ErrTaskNotFound", err)
+	}
+	tasks, err := store.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != other.ID {
+		t.Errorf("GetAllTasks() after Delete() = %+v, want only %q", tasks, other.ID)
+	}
+}
+
+func testDeleteNotFound(t *testing.T, store repository.TaskStore) {
+	err := store.Delete(context.Background(), "missing")
+	if !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("Delete() error = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+	if _, err := store.AddItem(ctx, task.ID, item("http://example.com/a.pdf")); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+
+	got := mustGet(t, store, task.ID)
+	got.Name = "changed"
+	got.Status = models.StatusFailed
+	got.Items[0].URL = "http://example.com/changed.pdf"
+	got.Items[0].Attempts[0].StatusCode = 500
+
+	all, err := store.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	all[0].Name = "changed too"
+
+	again := mustGet(t, store, task.ID)
+	if again.Name != "docs" || again.Status != models.StatusInProcess ||
+		!reflect.DeepEqual(again.Items[0], item("http://example.com/a.pdf")) {
+		t.Errorf("store state was modified through a returned task: %+v", again)
+	}
+}
+
+func testCanceledContext(t *testing.T, store repository.TaskStore) {
+	task := mustCreate(t, store, "docs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.Create(ctx, &models.Task{Name: "docs"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create() error = %v, want context.Canceled", err)
+	}
+	if _, err := store.GetTaskByID(ctx, task.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetTaskByID() error = %v, want context.Canceled", err)
+	}
+	if _, err := store.AddItem(ctx, task.ID, item("http://example.com/a.pdf")); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddItem() error = %v, want context.Canceled", err)
+	}
+	if err := store.UpdateItem(ctx, task.ID, 0, item("http://example.com/a.pdf")); !errors.Is(err, context.Canceled) {
+		t.Errorf("UpdateItem() error = %v, want context.Canceled", err)
+	}
+	if err := store.UpdateTaskStatus(ctx, task.ID, models.StatusFailed); !errors.Is(err, context.Canceled) {
+		t.Errorf("UpdateTaskStatus() error = %v, want context.Canceled", err)
+	}
+	if err := store.UpdateTask(ctx, task.ID, models.Archive{}, models.StatusFailed); !errors.Is(err, context.Canceled) {
+		t.Errorf("UpdateTask() error = %v, want context.Canceled", err)
+	}
+	if _, err := store.GetAllTasks(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetAllTasks() error = %v, want context.Canceled", err)
+	}
+	if err := store.Delete(ctx, task.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("Delete() error = %v, want context.Canceled", err)
+	}
+
+	if got := mustGet(t, store, task.ID); got.Status != models.StatusCreated || len(got.Items) != 0 {
+		t.Errorf("canceled calls modified the task: %+v", got)
+	}
+}
+
+func testConcurrentAddItem(t *testing.T, store repository.TaskStore) {
+	ctx := context.Background()
+	task := mustCreate(t, store, "docs")
+
+	const workers = 10
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.AddItem(ctx, task.ID, item(fmt.Sprintf("http://example.com/%d.pdf", i)))
+			switch {
+			case err == nil:
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			case !errors.Is(err, repository.ErrURLLimitReached):
+				t.Errorf("AddItem() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := mustGet(t, store, task.ID)
+	if accepted != MaxURLs || len(got.Items) != MaxURLs {
+		t.Errorf("accepted %d items and stored %d, want %d", accepted, len(got.Items), MaxURLs)
+	}
+}
@@ -1,141 +1,160 @@
 package repository
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
-	"github.com/google/uuid"
-	"github.com/pingcap/errors"
 )
 
 type TaskRepository struct {
-	tasks map[string]*models.Task
-	mu    sync.Mutex
+	tasks    map[string]*models.Task
+	maxURLs  int
+	maxBytes int64
+	mu       sync.Mutex
 }
 
-func NewTaskRepository() *TaskRepository {
+// NewTaskRepository creates an in-memory store accepting at most maxURLs
+// files and maxBytes bytes (zero for no limit) per task.
+func NewTaskRepository(maxURLs int, maxBytes int64) *TaskRepository {
 	return &TaskRepository{
-		tasks: make(map[string]*models.Task),
+		tasks:    make(map[string]*models.Task),
+		maxURLs:  maxURLs,
+		maxBytes: maxBytes,
 	}
 }
 
-func (r *TaskRepository) Create(task *models.Task) (*models.Task, error) {
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	newTask := &models.Task{
-		ID:        generateID(),
-		Name:      task.Name,
-		Status:    models.StatusCreated,
-		URLs:      []string{},
-		Errors:    []string{},
-		ZipPath:   "",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
+	newTask := newTask(task)
 	r.tasks[newTask.ID] = newTask
-	return newTask, nil
+	return cloneTask(newTask), nil
 }
 
-func generateID() string {
-	return uuid.New().String()
-}
+func (r *TaskRepository) GetAllTasks(ctx context.Context) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-func (r *TaskRepository) GetAllTasks() ([]*models.Task, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.tasks == nil {
-		return nil, errors.New("storage temporarily unavailable")
-	}
-
 	tasks := make([]*models.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		tasks = append(tasks, task)
+		tasks = append(tasks, cloneTask(task))
 	}
 
 	return tasks, nil
 }
 
-func (r *TaskRepository) AddURL(taskID string, url string) error {
+func (r *TaskRepository) AddItem(ctx context.Context, taskID string, item models.TaskItem) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	task, exists := r.tasks[taskID]
 	if !exists {
-		return errors.New("task not found")
-	}
-
-	if len(task.URLs) >= 3 {
-		return errors.New("Validation Error. max 3 files per task")
+		return 0, ErrTaskNotFound
 	}
 
-	task.URLs = append(task.URLs, url)
-	if task.Status == models.StatusCreated {
-		task.Status = models.StatusInProcess
-	}
+	return addItem(task, item, r.maxURLs, r.maxBytes)
+}
 
-	if len(task.URLs) == 3 {
-		task.Status = models.StatusCompleted
+func (r *TaskRepository) UpdateItem(ctx context.Context, taskID string, index int, item models.TaskItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	task.UpdatedAt = time.Now()
-	return nil
-}
-
-func (r *TaskRepository) GetTaskByID(id string) (*models.Task, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	task, exists := r.tasks[id]
+	task, exists := r.tasks[taskID]
 	if !exists {
-		return nil, errors.New("task not found")
+		return ErrTaskNotFound
 	}
-	return task, nil
+
+	return updateItem(task, index, item)
 }
 
-func (r *TaskRepository) GetTask(id string) (*models.Task, error) {
+func (r *TaskRepository) GetTaskByID(ctx context.Context, id string) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, errors.New("task not found")
+		return nil, ErrTaskNotFound
 	}
-	return task, nil
+	return cloneTask(task), nil
 }
 
-func (r *TaskRepository) UpdateTaskStatus(id string, status models.TaskStatus) error {
+func (r *TaskRepository) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if task, exists := r.tasks[id]; exists {
 		task.Status = status
+		task.UpdatedAt = time.Now()
 		return nil
 	}
-	return errors.New("task not found")
+	return ErrTaskNotFound
 }
 
 func (r *TaskRepository) UpdateTask(
+	ctx context.Context,
 	taskID string,
-	zipPath string,
+	archive models.Archive,
 	status models.TaskStatus,
-	myErrors []string,
 ) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	task, exists := r.tasks[taskID]
 	if !exists {
-		return errors.New("task not found")
+		return ErrTaskNotFound
 	}
 
-	task.ZipPath = zipPath
+	task.ZipPath = archive.Path
+	task.ArchiveSize = archive.Size
+	task.ArchiveSHA256 = archive.SHA256
 	task.Status = status
-	task.Errors = myErrors
 	task.UpdatedAt = time.Now()
 
 	return nil
 }
+
+func (r *TaskRepository) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[id]; !exists {
+		return ErrTaskNotFound
+	}
+	delete(r.tasks, id)
+	return nil
+}
@@ -0,0 +1,27 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository/storetest"
+)
+
+func TestTaskRepository(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) repository.TaskStore {
+		return repository.NewTaskRepository(storetest.MaxURLs, storetest.MaxBytes)
+	})
+}
+
+func TestBoltTaskRepository(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) repository.TaskStore {
+		path := filepath.Join(t.TempDir(), "tasks.db")
+		repo, err := repository.NewBoltTaskRepository(path, storetest.MaxURLs, storetest.MaxBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+)
+
+var (
+	// ErrTaskNotFound is returned when no task with the given ID exists.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrURLLimitReached is returned by AddItem once the task holds the maximum
+	// number of files.
+	ErrURLLimitReached = errors.New("Validation Error. max files per task reached")
+	// ErrSizeLimitReached is returned by AddItem when the item would make the
+	// task's files exceed the maximum total size.
+	ErrSizeLimitReached = errors.New("Validation Error. max total size per task reached")
+	// ErrTaskClosed is returned by AddItem when the task no longer accepts
+	// files.
+	ErrTaskClosed = errors.New("task does not accept new files")
+	// ErrItemNotFound is returned by UpdateItem when the task has no item at
+	// the given index.
+	ErrItemNotFound = errors.New("task item not found")
+)
+
+// TaskStore is implemented by every task storage backend. Implementations
+// must be safe for concurrent use and must return copies of stored tasks, so
+// callers never share state with the store or with each other.
+//
+// Every implementation is expected to pass the storetest contract suite.
+type TaskStore interface {
+	Create(ctx context.Context, task *models.Task) (*models.Task, error)
+	// AddItem appends an item and returns its index.
+	AddItem(ctx context.Context, taskID string, item models.TaskItem) (int, error)
+	// UpdateItem replaces the item at index, e.g. to record new attempts.
+	UpdateItem(ctx context.Context, taskID string, index int, item models.TaskItem) error
+	GetTaskByID(ctx context.Context, id string) (*models.Task, error)
+	// UpdateTask records the archive of a task together with its status.
+	UpdateTask(ctx context.Context, taskID string, archive models.Archive, status models.TaskStatus) error
+	UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus) error
+	GetAllTasks(ctx context.Context) ([]*models.Task, error)
+	Delete(ctx context.Context, id string) error
+}
+
+func generateID() string {
+	return uuid.New().String()
+}
+
+func newTask(task *models.Task) *models.Task {
+	now := time.Now()
+	return &models.Task{
+		ID:        generateID(),
+		Name:      task.Name,
+		Status:    models.StatusCreated,
+		Items:     []models.TaskItem{},
+		Format:    task.Format,
+		Ephemeral: task.Ephemeral,
+		Manifest:  task.Manifest,
+		ZipPath:   "",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func cloneTask(task *models.Task) *models.Task {
+	clone := *task
+	clone.Items = make([]models.TaskItem, len(task.Items))
+	for i, item := range task.Items {
+		item.Attempts = append([]models.Attempt(nil), item.Attempts...)
+		clone.Items[i] = item
+	}
+	return &clone
+}
+
+// addItem appends item to task and returns its index. Failed items do not
+// count towards the file limit, so they can be replaced. maxBytes limits the
+// total size of the task's files; zero means no limit.
+func addItem(task *models.Task, item models.TaskItem, maxURLs int, maxBytes int64) (int, error) {
+	if !task.Status.AcceptsItems() {
+		return 0, ErrTaskClosed
+	}
+	if len(task.Items)-task.CountItems(models.ItemStateFailed) >= maxURLs {
+		return 0, ErrURLLimitReached
+	}
+	if maxBytes > 0 && task.Size()+item.Size > maxBytes {
+		return 0, ErrSizeLimitReached
+	}
+
+	item.Attempts = append([]models.Attempt(nil), item.Attempts...)
+	task.Items = append(task.Items, item)
+	if task.Status == models.StatusCreated {
+		task.Status = models.StatusInProcess
+	}
+
+	task.UpdatedAt = time.Now()
+	return len(task.Items) - 1, nil
+}
+
+func updateItem(task *models.Task, index int, item models.TaskItem) error {
+	if index < 0 || index >= len(task.Items) {
+		return ErrItemNotFound
+	}
+
+	item.Attempts = append([]models.Attempt(nil), item.Attempts...)
+	task.Items[index] = item
+	task.UpdatedAt = time.Now()
+	return nil
+}
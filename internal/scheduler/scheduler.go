@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrStopped is returned by Enqueue once the scheduler has been stopped.
+var ErrStopped = errors.New("scheduler is stopped")
+
+// Job processes a single task. The context is canceled when the scheduler is
+// forced to stop.
+type Job func(ctx context.Context, taskID string)
+
+// Scheduler runs jobs on a fixed-size pool of workers. Tasks are processed in
+// FIFO order; while a task waits for a free worker its position in the queue
+// can be queried.
+type Scheduler struct {
+	workers int
+	job     Job
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []string
+	running map[string]struct{}
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(workers int, job Job) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		workers: workers,
+		job:     job,
+		running: make(map[string]struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Start launches the worker pool.
+func (s *Scheduler) Start() {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+}
+
+// Enqueue appends a task to the queue. Enqueuing a task that is already
+// queued or running is a no-op.
+func (s *Scheduler) Enqueue(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+	if _, ok := s.running[taskID]; ok || s.indexOf(taskID) >= 0 {
+		return nil
+	}
+
+	s.queue = append(s.queue, taskID)
+	s.cond.Signal()
+	return nil
+}
+
+// Position returns the 1-based position of a waiting task in the queue.
+// It returns false when the task is not waiting.
+func (s *Scheduler) Position(taskID string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(taskID)
+	if i < 0 {
+		return 0, false
+	}
+	return i + 1, true
+}
+
+// Remove drops a waiting task from the queue. It returns false when the task
+// is not waiting, e.g. because it is already running.
+func (s *Scheduler) Remove(taskID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(taskID)
+	if i < 0 {
+		return false
+	}
+	s.queue = append(s.queue[:i], s.queue[i+1:]...)
+	return true
+}
+
+// Stop stops accepting new tasks and waits for running jobs to finish.
+// Tasks still waiting in the queue are dropped. If ctx expires first, running
+// jobs are canceled and ctx.Err() is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.queue = nil
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) worker() {
+	defer s.wg.Done()
+
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if s.stopped {
+			s.mu.Unlock()
+			return
+		}
+
+		taskID := s.queue[0]
+		s.queue = s.queue[1:]
+		s.running[taskID] = struct{}{}
+		s.mu.Unlock()
+
+		s.job(s.ctx, taskID)
+
+		s.mu.Lock()
+		delete(s.running, taskID)
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) indexOf(taskID string) int {
+	for i, id := range s.queue {
+		if id == taskID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFIFO(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	release := make(chan struct{})
+	s := New(1, func(ctx context.Context, taskID string) {
+		if taskID == "first" {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, taskID)
+		mu.Unlock()
+	})
+	s.Start()
+
+	for _, id := range []string{"first", "a", "b", "c"} {
+		if err := s.Enqueue(id); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+	waitFor(t, func() bool {
+		_, waiting := s.Position("first")
+		return !waiting
+	})
+
+	if pos, ok := s.Position("c"); !ok || pos != 3 {
+		t.Errorf("Position(c) = %d, %v, want 3, true", pos, ok)
+	}
+	if err := s.Enqueue("a"); err != nil {
+		t.Fatalf("Enqueue(a) again error = %v", err)
+	}
+	if !s.Remove("b") {
+		t.Error("Remove(b) = false, want true")
+	}
+	if s.Remove("first") {
+		t.Error("Remove() of a running task = true, want false")
+	}
+
+	close(release)
+	if err := s.Stop(waitUntilIdle(t, s)); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if want := []string{"first", "a", "c"}; !slices.Equal(order, want) {
+		t.Errorf("jobs ran in order %v, want %v", order, want)
+	}
+}
+
+func TestWorkerLimit(t *testing.T) {
+	var running, peak atomic.Int32
+	var done sync.WaitGroup
+	s := New(3, func(ctx context.Context, taskID string) {
+		defer done.Done()
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+	})
+	s.Start()
+
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		done.Add(1)
+		if err := s.Enqueue(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done.Wait()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() != 3 {
+		t.Errorf("peak concurrency = %d, want 3", peak.Load())
+	}
+}
+
+func TestStopWaitsForJobs(t *testing.T) {
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s := New(1, func(ctx context.Context, taskID string) {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(ctx.Err() == nil)
+	})
+	s.Start()
+	s.Enqueue("a")
+	s.Enqueue("dropped")
+	<-started
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Stop() returned before the running job finished")
+	}
+	if err := s.Enqueue("late"); !errors.Is(err, ErrStopped) {
+		t.Errorf("Enqueue() after Stop() error = %v, want ErrStopped", err)
+	}
+	if _, ok := s.Position("dropped"); ok {
+		t.Error("a waiting task survived Stop()")
+	}
+}
+
+func TestStopCancelsJobsOnTimeout(t *testing.T) {
+	started := make(chan struct{})
+	s := New(1, func(ctx context.Context, taskID string) {
+		close(started)
+		<-ctx.Done()
+	})
+	s.Start()
+	s.Enqueue("a")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+// waitUntilIdle returns a context for Stop once nothing is waiting.
+func waitUntilIdle(t *testing.T, s *Scheduler) context.Context {
+	t.Helper()
+	waitFor(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.queue) == 0 && len(s.running) == 0
+	})
+	return context.Background()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,130 +1,629 @@
 package service
 
 import (
-	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/config"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/pkg/safehttp"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 )
 
 type ArchiveService interface {
-	CreateArchive(taskID string, urls []string) error
+	// ProbeItem validates a file for a task from its first bytes and the
+	// size the server announces, without downloading it. maxBytes is the
+	// space left in the task, zero means no limit; the per-file limit
+	// applies either way.
+	ProbeItem(ctx context.Context, taskID string, url string, maxBytes int64) (models.TaskItem, error)
+	// CreateArchive downloads the files of a task straight into an archive
+	// in the given format, records the outcome of every file and returns the
+	// path, size and SHA-256 of the archive. Marking the task completed is
+	// left to the caller.
+	CreateArchive(ctx context.Context, task *models.Task, format archive.Format) (models.Archive, error)
+	// StreamArchive streams the files of a task into an archive written to
+	// w without storing anything, and returns the items with the outcome of
+	// every download. A file failing mid-stream is cut short and marked as
+	// failed instead of aborting the archive.
+	StreamArchive(ctx context.Context, task *models.Task, format archive.Format, w io.Writer) ([]models.TaskItem, error)
+	// VerifyArchive reads back the archive of a completed task and reports
+	// where it differs from what was recorded when it was built.
+	VerifyArchive(ctx context.Context, task *models.Task, format archive.Format) (Verification, error)
+	// Cleanup removes a partially written archive of a task.
+	Cleanup(taskID string) error
+	// Remove deletes every file of a task: payloads and the archive.
+	Remove(taskID string) error
+	// RemoveTemp deletes payloads kept by earlier versions, which archived
+	// files from disk.
+	RemoveTemp(taskID string) error
+	// StoredTaskIDs lists the tasks that have an archive or payloads on disk.
+	StoredTaskIDs() ([]string, error)
 }
 
-func NewArchiveServiceImpl(repo *repository.TaskRepository, storagePath string) *ArchiveServiceImpl {
+func NewArchiveServiceImpl(
+	repo repository.TaskStore,
+	storageCfg config.StorageConfig,
+	tasksCfg config.TasksConfig,
+	downloadCfg config.DownloadConfig,
+	filePolicy policy.Policy,
+	guard *safehttp.Guard,
+) *ArchiveServiceImpl {
+	client := guard.Client(downloadCfg.Timeout, downloadCfg.MaxRedirects)
+	retry := RetryPolicy{
+		MaxAttempts: downloadCfg.Retry.MaxAttempts,
+		BaseDelay:   downloadCfg.Retry.BaseDelay,
+		MaxDelay:    downloadCfg.Retry.MaxDelay,
+		Jitter:      downloadCfg.Retry.Jitter,
+	}
 	return &ArchiveServiceImpl{
-		repo:        repo,
-		storagePath: storagePath,
+		repo:            repo,
+		storagePath:     storageCfg.Path,
+		downloader:      NewDownloader(client, downloadCfg.SniffBytes, filePolicy, retry, downloadCfg.MaxConcurrent),
+		taskSlots:       newTaskSlots(downloadCfg.TaskParallelism),
+		taskParallelism: downloadCfg.TaskParallelism,
+		maxFileSize:     downloadCfg.MaxFileSize,
+		maxTaskSize:     tasksCfg.MaxSize,
 	}
 }
 
 type ArchiveServiceImpl struct {
-	repo        *repository.TaskRepository
-	storagePath string
+	repo            repository.TaskStore
+	storagePath     string
+	downloader      *Downloader
+	taskSlots       *taskSlots
+	taskParallelism int
+	maxFileSize     int64
+	maxTaskSize     int64
 }
 
-func (s *ArchiveServiceImpl) CreateArchive(taskID string, urls []string) error {
-	tmpDir := filepath.Join(s.storagePath, "tmp", taskID)
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+func (s *ArchiveServiceImpl) ProbeItem(ctx context.Context, taskID string, url string, maxBytes int64) (models.TaskItem, error) {
+	release, err := s.taskSlots.acquire(ctx, taskID)
+	if err != nil {
+		return models.TaskItem{}, err
+	}
+	defer release()
+
+	limit := s.maxFileSize
+	if maxBytes > 0 && (limit == 0 || maxBytes < limit) {
+		limit = maxBytes
+	}
+	probed, err := s.downloader.Probe(ctx, url, limit)
+	var sizeErr *SizeError
+	if errors.As(err, &sizeErr) && limit != s.maxFileSize &&
+		(s.maxFileSize == 0 || (sizeErr.Size > 0 && sizeErr.Size <= s.maxFileSize)) {
+		// The file itself is acceptable, it just does not fit into the task.
+		return models.TaskItem{}, fmt.Errorf("%w: %w", repository.ErrSizeLimitReached, err)
+	}
+	if err != nil {
+		return models.TaskItem{}, err
 	}
-	defer os.RemoveAll(tmpDir)
 
-	var downloadedFiles []string
-	var errors []string
+	return models.TaskItem{
+		URL:       url,
+		State:     models.ItemStateValidated,
+		MIME:      probed.MIME,
+		Size:      probed.Size,
+		Validator: probed.Validator,
+		Attempts:  probed.Attempts,
+	}, nil
+}
 
-	for _, url := range urls {
-		fileName := filepath.Base(url)
-		filePath := filepath.Join(tmpDir, fileName)
+func (s *ArchiveServiceImpl) CreateArchive(ctx context.Context, task *models.Task, format archive.Format) (models.Archive, error) {
+	taskID, items := task.ID, task.Items
 
-		resp, err := http.Get(url)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("%s: %v", url, err))
-			continue
+	// The archive is written under a temporary name so a download never
+	// sees a partial file, and moved into place once it is complete.
+	archivePath := s.archivePath(taskID, format)
+	partialPath := archivePath + partialSuffix
+
+	// Every file is recorded as it is downloaded and once it is in the
+	// archive, so the task shows the progress of the archive.
+	recordCtx := context.WithoutCancel(ctx)
+	record := func(index int, item models.TaskItem) {
+		if err := s.repo.UpdateItem(recordCtx, taskID, index, item); err != nil {
+			log.Printf("Failed to record progress of %s for task %s: %v", item.URL, taskID, err)
 		}
-		defer resp.Body.Close()
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			errors = append(errors, fmt.Sprintf("%s: server returned %d", url, resp.StatusCode))
+	archived := append([]models.TaskItem{}, items...)
+	built, err := s.writeArchive(ctx, partialPath, format, task, archived, record)
+	if err == nil {
+		err = os.Rename(partialPath, archivePath)
+	}
+	if err != nil {
+		os.Remove(partialPath)
+	}
+
+	// Outcomes are recorded even if the archive failed, so the task shows
+	// which file broke it.
+	for i, item := range archived {
+		if items[i].State == models.ItemStateFailed {
 			continue
 		}
+		if err != nil && item.State != models.ItemStateFailed {
+			// Without an archive the file waits to be archived again.
+			item.State = models.ItemStateValidated
+			item.EntryName = ""
+		}
+		if recordErr := s.repo.UpdateItem(recordCtx, taskID, i, item); recordErr != nil && err == nil {
+			return models.Archive{}, fmt.Errorf("failed to record item %s: %w", item.URL, recordErr)
+		}
+	}
+	if err != nil {
+		return models.Archive{}, fmt.Errorf("%s creation failed: %w", format, err)
+	}
 
-		outFile, err := os.Create(filePath)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("%s: failed to create file - %v", url, err))
+	// Payloads kept by earlier versions are no longer needed.
+	if err := s.RemoveTemp(taskID); err != nil {
+		log.Printf("Failed to remove payloads of task %s: %v", taskID, err)
+	}
+
+	built.Path = archivePath
+	return built, nil
+}
+
+func (s *ArchiveServiceImpl) StreamArchive(ctx context.Context, task *models.Task, format archive.Format, w io.Writer) ([]models.TaskItem, error) {
+	streamed := append([]models.TaskItem{}, task.Items...)
+
+	writer, err := archive.NewWriter(format, w)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.writeEntries(ctx, writer, task, streamed, true, nil); err != nil {
+		return streamed, err
+	}
+	return streamed, writer.Close()
+}
+
+// writeArchive downloads the items straight into an archive file at
+// archivePath and returns the size and hash of the file.
+func (s *ArchiveServiceImpl) writeArchive(ctx context.Context, archivePath string, format archive.Format, task *models.Task, items []models.TaskItem, record recordFunc) (models.Archive, error) {
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		return models.Archive{}, fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer archiveFile.Close()
+
+	digest := newDigestWriter(archiveFile)
+	writer, err := archive.NewWriter(format, digest)
+	if err != nil {
+		return models.Archive{}, err
+	}
+	if err := s.writeEntries(ctx, writer, task, items, false, record); err != nil {
+		return models.Archive{}, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return models.Archive{}, err
+	}
+	if err := archiveFile.Sync(); err != nil {
+		return models.Archive{}, err
+	}
+	if err := archiveFile.Close(); err != nil {
+		return models.Archive{}, err
+	}
+	return models.Archive{Size: digest.size, SHA256: digest.Sum()}, nil
+}
+
+// recordFunc persists the state of the item at index while an archive is
+// written.
+type recordFunc func(index int, item models.TaskItem)
+
+// writeEntries downloads the items of task in order into writer and records
+// the outcome on each item. Items rejected during validation are left out.
+// Small files are downloaded ahead concurrently, the others when their entry
+// is written. With keepGoing, a file failing after its entry was started
+// does not abort the archive. The manifest, if the task asks for one, comes
+// last. record, if not nil, is called when a file starts downloading and
+// once it is written.
+func (s *ArchiveServiceImpl) writeEntries(ctx context.Context, writer archive.Writer, task *models.Task, items []models.TaskItem, keepGoing bool, record recordFunc) error {
+	if record == nil {
+		record = func(int, models.TaskItem) {}
+	}
+
+	used := map[string]bool{}
+	if task.Manifest {
+		// Files are renamed rather than the manifest, so recipients always
+		// find it under the same name.
+		used[manifestName] = true
+		used[checksumsName] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ahead := s.prefetch(ctx, task.ID, items, record)
+	defer func() {
+		cancel()
+		<-ahead.stopped
+	}()
+
+	added := 0
+	for i := range items {
+		item := &items[i]
+		if item.State == models.ItemStateFailed {
 			continue
 		}
 
-		_, err = io.Copy(outFile, resp.Body)
-		defer outFile.Close()
+		name := item.Filename
+		if name == "" {
+			name = entryName(item.URL, added)
+		}
+		name = uniqueName(name, used)
 
+		var err error
+		if f, ok := ahead.files[i]; ok {
+			if err = writeFetched(ctx, writer, name, item, f); err == nil {
+				ahead.release(i)
+			}
+		} else {
+			item.State = models.ItemStateDownloading
+			record(i, *item)
+			err = s.streamItem(ctx, writer, task.ID, name, items, i, keepGoing)
+		}
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("%s: failed to save content - %v", url, err))
-			continue
+			return fmt.Errorf("failed to add file %s to archive: %w", name, err)
+		}
+		record(i, *item)
+		if item.EntryName == "" {
+			// The name is free for the next file.
+			delete(used, name)
+		}
+		if item.State == models.ItemStateDone {
+			added++
 		}
+	}
+	log.Printf("Added %d files to archive, %d skipped", added, len(items)-added)
 
-		downloadedFiles = append(downloadedFiles, filePath)
+	if task.Manifest {
+		if err := writeManifest(writer, task, items); err != nil {
+			return fmt.Errorf("failed to add manifest to archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeFetched adds an item downloaded ahead into a new archive entry named
+// name, or leaves it out if the download failed.
+func writeFetched(ctx context.Context, writer archive.Writer, name string, item *models.TaskItem, f *fetched) error {
+	item.EntryName = ""
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if f.err != nil {
+		return finishItem(ctx, item, name, f.download, f.err, false, false)
+	}
 
-		log.Printf("Added %d files to archive, %d errors", len(downloadedFiles), len(errors))
+	entry, err := writer.Create(name, f.download.Size, time.Now())
+	if err != nil {
+		return err
 	}
+	if _, err := f.data.WriteTo(entry); err != nil {
+		return err
+	}
+	return finishItem(ctx, item, name, f.download, nil, true, false)
+}
 
-	zipPath := filepath.Join(s.storagePath, fmt.Sprintf("%s.zip", taskID))
-	if err := s.createZipArchive(zipPath, downloadedFiles); err != nil {
-		return fmt.Errorf("zip creation failed: %w", err)
+// streamItem downloads items[index] into a new archive entry named name.
+func (s *ArchiveServiceImpl) streamItem(ctx context.Context, writer archive.Writer, taskID, name string, items []models.TaskItem, index int, keepGoing bool) error {
+	item := &items[index]
+	item.EntryName = ""
+
+	var maxBytes int64
+	if item.Size == 0 {
+		var err error
+		if maxBytes, err = s.spaceLeft(item.URL, items); err != nil {
+			return finishItem(ctx, item, name, Download{}, err, false, keepGoing)
+		}
 	}
 
-	return s.repo.UpdateTask(taskID, zipPath, models.StatusCompleted, errors)
+	var entry io.Writer
+	download, err := s.download(ctx, taskID, *item, maxBytes, func(_ string, size int64) (io.Writer, error) {
+		var err error
+		entry, err = writer.Create(name, size, time.Now())
+		return entry, err
+	})
+	return finishItem(ctx, item, name, download, err, entry != nil, keepGoing)
 }
 
-func (s *ArchiveServiceImpl) createZipArchive(zipPath string, files []string) error {
-	zipFile, err := os.Create(zipPath)
+// finishItem records the outcome of archiving item under name. A file that
+// failed before its entry was created is marked as failed and left out.
+// Once the entry exists the archive cannot skip it, so later failures,
+// including content that differs from the validated file, are returned
+// unless keepGoing is set.
+func finishItem(ctx context.Context, item *models.TaskItem, name string, download Download, err error, created, keepGoing bool) error {
+	// Attempts are appended to a copy so the caller's items stay untouched.
+	item.Attempts = append(append([]models.Attempt(nil), item.Attempts...), download.Attempts...)
+
 	if err != nil {
-		return fmt.Errorf("failed to create zip file: %w", err)
+		if ctx.Err() != nil {
+			return err
+		}
+		item.State = models.ItemStateFailed
+		item.ErrorCode = ErrorCode(err)
+		item.Error = err.Error()
+		if !created {
+			return nil
+		}
+		if !keepGoing {
+			return err
+		}
+		// The broken entry stays in the archive under its name.
+		item.EntryName = name
+		return nil
+	}
+
+	item.State = models.ItemStateDone
+	item.MIME = download.MIME
+	item.Size = download.Size
+	item.SHA256 = download.SHA256
+	item.EntryName = name
+	return nil
+}
+
+// download fetches an item validated by ProbeItem and copies it to the
+// writer returned by open. A file that no longer matches the validated type,
+// size or validator fails with ErrContentChanged. maxBytes limits files of
+// unknown size. The attempts are returned on failure too.
+func (s *ArchiveServiceImpl) download(ctx context.Context, taskID string, item models.TaskItem, maxBytes int64, open OpenFunc) (Download, error) {
+	release, err := s.taskSlots.acquire(ctx, taskID)
+	if err != nil {
+		return Download{}, err
+	}
+	defer release()
+
+	if item.Size > 0 {
+		maxBytes = item.Size
+	}
+	download, err := s.downloader.Fetch(ctx, item.URL, item.Validator, maxBytes, func(mime string, size int64) (io.Writer, error) {
+		if item.MIME != "" && mime != item.MIME {
+			return nil, fmt.Errorf("%w: type %s, validated %s", ErrContentChanged, mime, item.MIME)
+		}
+		if item.Size > 0 {
+			if size >= 0 && size != item.Size {
+				return nil, fmt.Errorf("%w: %d bytes, validated %d", ErrContentChanged, size, item.Size)
+			}
+			size = item.Size
+		}
+		return open(mime, size)
+	})
+
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) {
+		download.Attempts = fetchErr.Attempts
+	}
+	var sizeErr *SizeError
+	switch {
+	case item.Size > 0 && errors.As(err, &sizeErr):
+		err = fmt.Errorf("%w: larger than the validated %d bytes: %w", ErrContentChanged, item.Size, err)
+	case err == nil && item.Size > 0 && download.Size != item.Size:
+		err = fmt.Errorf("%w: %d bytes, validated %d", ErrContentChanged, download.Size, item.Size)
+	case err == nil && item.SHA256 != "" && download.SHA256 != item.SHA256:
+		// Items validated by earlier versions carry the hash of the file.
+		err = fmt.Errorf("%w: sha256 %s, validated %s", ErrContentChanged, download.SHA256, item.SHA256)
+	}
+	return download, err
+}
+
+// spaceLeft returns the limit for a file of unknown size: the per-file
+// limit or the space the other files leave in the task, whichever is
+// smaller. Zero means no limit.
+func (s *ArchiveServiceImpl) spaceLeft(url string, items []models.TaskItem) (int64, error) {
+	if s.maxTaskSize == 0 {
+		return s.maxFileSize, nil
 	}
-	defer zipFile.Close()
+	left := s.maxTaskSize
+	for _, item := range items {
+		if item.State != models.ItemStateFailed {
+			left -= item.Size
+		}
+	}
+	if left <= 0 {
+		return 0, &SizeError{URL: url, Limit: s.maxTaskSize}
+	}
+	if s.maxFileSize > 0 {
+		left = min(left, s.maxFileSize)
+	}
+	return left, nil
+}
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+// prefetchBytes bounds the memory an archive uses for files downloaded
+// ahead of the entry being written.
+const prefetchBytes = 16 << 20
+
+// fetched is a file downloaded ahead into memory.
+type fetched struct {
+	index    int
+	item     models.TaskItem
+	done     chan struct{}
+	data     bytes.Buffer
+	download Download
+	err      error
+}
+
+// prefetcher downloads files ahead of the archive writer.
+type prefetcher struct {
+	files  map[int]*fetched
+	memory *semaphore.Weighted
+	// stopped is closed once no download is running any more.
+	stopped chan struct{}
+}
 
-	for _, file := range files {
-		if err := s.addFileToZip(zipWriter, file); err != nil {
-			return fmt.Errorf("failed to add file %s to zip: %w", file, err)
+// prefetch starts downloading the items of known size that fit into
+// prefetchBytes, in order and at most taskParallelism at a time. The others
+// are left to streamItem.
+func (s *ArchiveServiceImpl) prefetch(ctx context.Context, taskID string, items []models.TaskItem, record recordFunc) *prefetcher {
+	p := &prefetcher{
+		files:   map[int]*fetched{},
+		memory:  semaphore.NewWeighted(prefetchBytes),
+		stopped: make(chan struct{}),
+	}
+	var order []*fetched
+	for i, item := range items {
+		if item.State != models.ItemStateFailed && item.Size > 0 && item.Size <= prefetchBytes {
+			p.files[i] = &fetched{index: i, item: item, done: make(chan struct{})}
+			order = append(order, p.files[i])
 		}
 	}
 
+	go func() {
+		defer close(p.stopped)
+
+		var g errgroup.Group
+		g.SetLimit(s.taskParallelism)
+		for _, f := range order {
+			// Memory is reserved in order, so the file written next never
+			// waits for a later one.
+			if err := p.memory.Acquire(ctx, f.item.Size); err != nil {
+				break
+			}
+			g.Go(func() error {
+				defer close(f.done)
+				downloading := f.item
+				downloading.State = models.ItemStateDownloading
+				record(f.index, downloading)
+				f.download, f.err = s.download(ctx, taskID, f.item, 0, func(string, int64) (io.Writer, error) {
+					f.data.Grow(int(f.item.Size))
+					return &f.data, nil
+				})
+				return nil
+			})
+		}
+		g.Wait()
+	}()
+	return p
+}
+
+// release frees the memory of a file once its entry is written.
+func (p *prefetcher) release(index int) {
+	f := p.files[index]
+	delete(p.files, index)
+	f.data = bytes.Buffer{}
+	p.memory.Release(f.item.Size)
+}
+
+func (s *ArchiveServiceImpl) Cleanup(taskID string) error {
+	if err := s.removeArchives(taskID); err != nil {
+		return fmt.Errorf("failed to remove partial archive: %w", err)
+	}
 	return nil
 }
 
-func (s *ArchiveServiceImpl) addFileToZip(zipWriter *zip.Writer, filePath string) error {
-	fileToZip, err := os.Open(filePath)
-	if err != nil {
-		return err
+func (s *ArchiveServiceImpl) Remove(taskID string) error {
+	if err := os.RemoveAll(s.tmpDir(taskID)); err != nil {
+		return fmt.Errorf("failed to remove temporary files: %w", err)
+	}
+	if err := s.removeArchives(taskID); err != nil {
+		return fmt.Errorf("failed to remove archive: %w", err)
 	}
-	defer fileToZip.Close()
+	return nil
+}
 
-	info, err := fileToZip.Stat()
-	if err != nil {
-		return err
+// removeArchives deletes the archive of a task in whatever format it was
+// built, complete or partial.
+func (s *ArchiveServiceImpl) removeArchives(taskID string) error {
+	for _, format := range archive.Formats {
+		archivePath := s.archivePath(taskID, format)
+		for _, path := range []string{archivePath, archivePath + partialSuffix} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
 	}
+	return nil
+}
 
-	header, err := zip.FileInfoHeader(info)
-	if err != nil {
-		return err
+func (s *ArchiveServiceImpl) RemoveTemp(taskID string) error {
+	if err := os.RemoveAll(s.tmpDir(taskID)); err != nil {
+		return fmt.Errorf("failed to remove temporary files: %w", err)
 	}
+	return nil
+}
 
-	header.Method = zip.Deflate
+func (s *ArchiveServiceImpl) StoredTaskIDs() ([]string, error) {
+	seen := map[string]bool{}
 
-	writer, err := zipWriter.CreateHeader(header)
-	if err != nil {
-		return err
+	entries, err := os.ReadDir(s.storagePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), partialSuffix)
+		for _, format := range archive.Formats {
+			if id, ok := strings.CutSuffix(name, format.Extension()); ok {
+				seen[id] = true
+			}
+		}
+	}
+
+	entries, err = os.ReadDir(filepath.Join(s.storagePath, "tmp"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list temporary files: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			seen[entry.Name()] = true
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *ArchiveServiceImpl) tmpDir(taskID string) string {
+	return filepath.Join(s.storagePath, "tmp", taskID)
+}
+
+func (s *ArchiveServiceImpl) archivePath(taskID string, format archive.Format) string {
+	return filepath.Join(s.storagePath, taskID+format.Extension())
+}
+
+// partialSuffix marks an archive that is still being written.
+const partialSuffix = ".partial"
+
+// uniqueName returns name, or name with a " (n)" suffix before the
+// extension if it is already used, and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	ext := path.Ext(name)
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
 	}
+	used[unique] = true
+	return unique
+}
 
-	_, err = io.Copy(writer, fileToZip)
-	return err
+// entryName derives the archive entry name from the source URL. Names that
+// are not valid entry names, such as ".." decoded from "%2e%2e", are
+// replaced by a generic one.
+func entryName(rawURL string, index int) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if name := path.Base(u.Path); archive.CheckName(name) == nil {
+			return name
+		}
+	}
+	return fmt.Sprintf("file-%d", index+1)
 }
@@ -0,0 +1,28 @@
+package service
+
+import "testing"
+
+func TestEntryName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/docs/report.pdf", "report.pdf"},
+		{"https://example.com/docs/report.pdf?x=1#top", "report.pdf"},
+		{"https://example.com/%D0%BE%D1%82%D1%87%D0%B5%D1%82.pdf", "отчет.pdf"},
+		{"https://example.com/", "file-3"},
+		{"https://example.com", "file-3"},
+		{"https://example.com/%2e%2e", "file-3"},
+		{"https://example.com/a/..", "file-3"},
+		{"https://example.com/..%5C..%5Cevil.pdf", "file-3"},
+		{"https://example.com/a%2Fb.pdf", "b.pdf"},
+		{"https://example.com/%00.pdf", "file-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := entryName(tt.url, 2); got != tt.want {
+				t.Errorf("entryName(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
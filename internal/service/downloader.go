@@ -0,0 +1,452 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Download describes a file fetched or probed by the Downloader.
+type Download struct {
+	MIME string
+	// Size is zero for a probed file whose size the server did not tell.
+	Size int64
+	// SHA256 is only set by Fetch.
+	SHA256 string
+	// Validator is the strong ETag or Last-Modified date of the file.
+	Validator string
+	Attempts  []models.Attempt
+}
+
+// FetchError is returned by Fetch when a file could not be downloaded. It
+// wraps the error of the last attempt.
+type FetchError struct {
+	URL      string
+	Attempts []models.Attempt
+	// Permanent is false when the last failure was transient but the
+	// attempts were exhausted.
+	Permanent bool
+	Err       error
+}
+
+func (e *FetchError) Error() string {
+	if len(e.Attempts) > 1 {
+		return fmt.Sprintf("%v (after %d attempts)", e.Err, len(e.Attempts))
+	}
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned for a response other than 200 OK.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v (status %d): %s", ErrFileUnavailable, e.StatusCode, e.URL)
+}
+
+func (e *StatusError) Unwrap() error {
+	return ErrFileUnavailable
+}
+
+// SizeError is returned when a file is larger than allowed.
+type SizeError struct {
+	URL string
+	// Size is the announced Content-Length, or zero if the limit was hit
+	// while reading the body.
+	Size  int64
+	Limit int64
+}
+
+func (e *SizeError) Error() string {
+	if e.Size > 0 {
+		return fmt.Sprintf("%v (%d bytes, limit %d): %s", ErrFileTooLarge, e.Size, e.Limit, e.URL)
+	}
+	return fmt.Sprintf("%v (over %d bytes): %s", ErrFileTooLarge, e.Limit, e.URL)
+}
+
+func (e *SizeError) Unwrap() error {
+	return ErrFileTooLarge
+}
+
+// Downloader streams source files to writers. The file type is detected
+// from the first bytes and checked against the policy before the rest of the
+// body is read. Transient failures are retried according to the retry policy;
+// a retry after a failure mid-body resumes where the previous attempt
+// stopped. At most maxConcurrent downloads run at a time, no matter how many
+// tasks are using the Downloader; the slot is released while waiting between
+// attempts.
+type Downloader struct {
+	client     *http.Client
+	sniffBytes int64
+	policy     policy.Policy
+	retry      RetryPolicy
+	slots      chan struct{}
+}
+
+func NewDownloader(
+	client *http.Client,
+	sniffBytes int64,
+	filePolicy policy.Policy,
+	retry RetryPolicy,
+	maxConcurrent int,
+) *Downloader {
+	return &Downloader{
+		client:     client,
+		sniffBytes: sniffBytes,
+		policy:     filePolicy,
+		retry:      retry,
+		slots:      make(chan struct{}, maxConcurrent),
+	}
+}
+
+// OpenFunc returns the writer a download is copied to. It is called once,
+// when the first response passed the type check, with the detected type and
+// the announced size of the file, -1 if unknown.
+type OpenFunc func(mime string, size int64) (io.Writer, error)
+
+// Probe validates url without downloading it: only the first bytes are
+// requested to detect the file type, and the size is taken from the
+// response headers. Files larger than maxBytes are rejected, zero means no
+// limit. On failure a *FetchError describing every attempt is returned.
+func (d *Downloader) Probe(ctx context.Context, url string, maxBytes int64) (Download, error) {
+	var probed Download
+	attempts, err := d.retrying(ctx, url, func() (outcome, error) {
+		return d.probe(ctx, url, maxBytes, &probed)
+	})
+	if err != nil {
+		return Download{}, err
+	}
+	probed.Attempts = attempts
+	return probed, nil
+}
+
+// Fetch downloads url and copies the body to the writer returned by open.
+// Every byte is written exactly once, even if attempts are retried. Files
+// larger than maxBytes are rejected, zero means no limit. A validator
+// returned by Probe makes the download fail with ErrContentChanged if the
+// file was modified since. On failure a *FetchError describing every attempt
+// is returned.
+func (d *Downloader) Fetch(ctx context.Context, url, validator string, maxBytes int64, open OpenFunc) (Download, error) {
+	t := &transfer{open: open, hash: sha256.New(), expect: validator, validator: validator}
+	attempts, err := d.retrying(ctx, url, func() (outcome, error) {
+		return d.fetch(ctx, url, maxBytes, t)
+	})
+	if err != nil {
+		return Download{}, err
+	}
+	return Download{
+		MIME:      t.mime,
+		Size:      t.written,
+		SHA256:    hex.EncodeToString(t.hash.Sum(nil)),
+		Validator: t.validator,
+		Attempts:  attempts,
+	}, nil
+}
+
+// retrying runs attempt until it succeeds, fails permanently or the retries
+// are exhausted, and returns every attempt made.
+func (d *Downloader) retrying(ctx context.Context, url string, attempt func() (outcome, error)) ([]models.Attempt, error) {
+	var attempts []models.Attempt
+	for n := 1; ; n++ {
+		started := time.Now()
+		outcome, err := d.withSlot(ctx, attempt)
+
+		record := models.Attempt{At: started, StatusCode: outcome.statusCode}
+		if err == nil {
+			return append(attempts, record), nil
+		}
+
+		record.Error = err.Error()
+		record.Transient = outcome.transient
+		attempts = append(attempts, record)
+
+		if !outcome.transient || n >= d.retry.MaxAttempts || ctx.Err() != nil {
+			return nil, &FetchError{URL: url, Attempts: attempts, Permanent: !outcome.transient, Err: err}
+		}
+
+		if err := sleep(ctx, d.retry.delay(n, outcome.retryAfter)); err != nil {
+			return nil, &FetchError{URL: url, Attempts: attempts, Err: err}
+		}
+	}
+}
+
+// transfer is the state of a download shared by its attempts. It is the
+// writer the body is copied to.
+type transfer struct {
+	open OpenFunc
+	dst  io.Writer
+	hash hash.Hash
+	mime string
+	// written counts the bytes passed to dst, where the next attempt resumes.
+	written int64
+	// expect is the validator the file must still match, if any.
+	expect string
+	// validator is the ETag or Last-Modified of the file, sent with resumed
+	// requests so a changed file is served in full.
+	validator string
+	// writeErr is set if dst failed, which is not worth a retry.
+	writeErr error
+}
+
+func (t *transfer) Write(p []byte) (int, error) {
+	n, err := t.dst.Write(p)
+	t.hash.Write(p[:n])
+	t.written += int64(n)
+	if err != nil {
+		t.writeErr = err
+	}
+	return n, err
+}
+
+// outcome classifies a single attempt.
+type outcome struct {
+	statusCode int
+	transient  bool
+	retryAfter time.Duration
+}
+
+func (d *Downloader) withSlot(ctx context.Context, attempt func() (outcome, error)) (outcome, error) {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return outcome{}, ctx.Err()
+	}
+	defer func() { <-d.slots }()
+
+	return attempt()
+}
+
+func (d *Downloader) probe(ctx context.Context, url string, maxBytes int64, probed *Download) (outcome, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return outcome{}, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", d.sniffBytes-1))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return outcome{transient: transientNetError(err)}, fmt.Errorf("failed to download file by path %v: %w", url, err)
+	}
+	// The rest of the body is not read, the connection is dropped instead.
+	defer resp.Body.Close()
+
+	result := outcome{statusCode: resp.StatusCode}
+	var size int64
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		size = resp.ContentLength
+	case resp.StatusCode == http.StatusPartialContent:
+		if size, err = rangeTotal(resp.Header.Get("Content-Range")); err != nil {
+			return result, err
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && resp.Header.Get("Content-Range") == "bytes */0":
+		// Servers answer so for an empty file.
+	default:
+		result.transient = transientStatus(resp.StatusCode)
+		result.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return result, &StatusError{URL: url, StatusCode: resp.StatusCode, RetryAfter: result.retryAfter}
+	}
+	if maxBytes > 0 && size > maxBytes {
+		return result, &SizeError{URL: url, Size: size, Limit: maxBytes}
+	}
+
+	head := make([]byte, d.sniffBytes)
+	n, err := io.ReadFull(resp.Body, head)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		if size < 0 {
+			// The whole file fit into the head.
+			size = int64(n)
+		}
+	case err != nil:
+		result.transient = transientNetError(err)
+		return result, fmt.Errorf("failed to read content: %w", err)
+	}
+	head = head[:n]
+
+	mime := mimetype.Detect(head)
+	if err := d.policy.Check(mime.String(), mime.Extension(), url); err != nil {
+		return result, err
+	}
+
+	probed.MIME = policy.BaseType(mime.String())
+	probed.Size = max(size, 0)
+	probed.Validator = rangeValidator(resp.Header)
+	return result, nil
+}
+
+func (d *Downloader) fetch(ctx context.Context, url string, maxBytes int64, t *transfer) (outcome, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return outcome{}, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	if t.expect != "" {
+		setPrecondition(req.Header, t.expect)
+	}
+	if t.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", t.written))
+		if t.validator != "" {
+			req.Header.Set("If-Range", t.validator)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return outcome{transient: transientNetError(err)}, fmt.Errorf("failed to download file by path %v: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	result := outcome{statusCode: resp.StatusCode}
+	if t.expect != "" && resp.StatusCode == http.StatusPreconditionFailed {
+		return result, fmt.Errorf("%w: %s", ErrContentChanged, url)
+	}
+	offset, err := responseOffset(resp, t.written)
+	if err != nil {
+		return result, err
+	}
+	if offset >= 0 && t.expect != "" && changedSince(resp.Header, t.expect) {
+		return result, fmt.Errorf("%w: %s", ErrContentChanged, url)
+	}
+	if offset < 0 {
+		result.transient = transientStatus(resp.StatusCode)
+		result.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return result, &StatusError{URL: url, StatusCode: resp.StatusCode, RetryAfter: result.retryAfter}
+	}
+	if maxBytes > 0 && resp.ContentLength >= 0 && offset+resp.ContentLength > maxBytes {
+		return result, &SizeError{URL: url, Size: offset + resp.ContentLength, Limit: maxBytes}
+	}
+
+	var body io.Reader = resp.Body
+	if t.written == 0 {
+		head := make([]byte, d.sniffBytes)
+		n, err := io.ReadFull(resp.Body, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			result.transient = transientNetError(err)
+			return result, fmt.Errorf("failed to read content: %w", err)
+		}
+		head = head[:n]
+
+		mime := mimetype.Detect(head)
+		if err := d.policy.Check(mime.String(), mime.Extension(), url); err != nil {
+			return result, err
+		}
+		if t.dst == nil {
+			t.mime = policy.BaseType(mime.String())
+			if t.dst, err = t.open(t.mime, resp.ContentLength); err != nil {
+				return result, fmt.Errorf("failed to save content: %w", err)
+			}
+			if t.validator == "" {
+				t.validator = rangeValidator(resp.Header)
+			}
+		}
+		body = io.MultiReader(bytes.NewReader(head), resp.Body)
+	} else if offset < t.written {
+		// The server ignored the range, skip what was already written.
+		if _, err := io.CopyN(io.Discard, resp.Body, t.written-offset); err != nil {
+			result.transient = transientNetError(err)
+			return result, fmt.Errorf("failed to read content: %w", err)
+		}
+	}
+
+	// Content-Length may be missing or wrong, so the body is cut off one byte
+	// past the limit to detect oversized files without reading them fully.
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes-t.written+1)
+	}
+
+	if _, err := io.Copy(t, body); err != nil {
+		if t.writeErr != nil {
+			return result, fmt.Errorf("failed to save content: %w", t.writeErr)
+		}
+		result.transient = transientNetError(err)
+		return result, fmt.Errorf("failed to read content: %w", err)
+	}
+	if maxBytes > 0 && t.written > maxBytes {
+		return result, &SizeError{URL: url, Limit: maxBytes}
+	}
+	return result, nil
+}
+
+// responseOffset returns the position in the file the body of resp starts
+// at, or -1 if the response carries no content. A ranged response must start
+// where the download is resumed.
+func responseOffset(resp *http.Response, resumeAt int64) (int64, error) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return 0, nil
+	case http.StatusPartialContent:
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || resumeAt == 0 || start != resumeAt {
+			return 0, fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+		}
+		return start, nil
+	default:
+		return -1, nil
+	}
+}
+
+// rangeTotal returns the file size from the Content-Range of a response to
+// a range starting at zero, -1 if the server did not tell.
+func rangeTotal(contentRange string) (int64, error) {
+	var end int64
+	var total string
+	if _, err := fmt.Sscanf(contentRange, "bytes 0-%d/%s", &end, &total); err != nil {
+		return 0, fmt.Errorf("unexpected content range %q", contentRange)
+	}
+	if total == "*" {
+		return -1, nil
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size <= end {
+		return 0, fmt.Errorf("unexpected content range %q", contentRange)
+	}
+	return size, nil
+}
+
+// setPrecondition makes a request fail with 412 unless the file still
+// matches validator.
+func setPrecondition(header http.Header, validator string) {
+	if strings.HasPrefix(validator, `"`) {
+		header.Set("If-Match", validator)
+	} else {
+		header.Set("If-Unmodified-Since", validator)
+	}
+}
+
+// changedSince reports whether a response is for another version of the
+// file than validator, for servers ignoring the precondition.
+func changedSince(header http.Header, validator string) bool {
+	current := header.Get("Last-Modified")
+	if strings.HasPrefix(validator, `"`) {
+		current = header.Get("ETag")
+	}
+	return current != "" && current != validator
+}
+
+// rangeValidator returns the validator for If-Range: a strong ETag or
+// otherwise the Last-Modified date.
+func rangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
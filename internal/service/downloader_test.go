@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
+)
+
+// pdf is a file mimetype detects as application/pdf, larger than the sniffed
+// prefix.
+var pdf = append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("0123456789abcdef"), 256)...)
+
+func TestProbe(t *testing.T) {
+	var served atomic.Int64
+	modTime := time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w = &countingWriter{ResponseWriter: w, n: &served}
+		switch r.URL.Path {
+		case "/etag.pdf":
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "etag.pdf", modTime, bytes.NewReader(pdf))
+		case "/chunked.pdf":
+			// No Content-Length and no range support.
+			w.Write(pdf[:100])
+			w.(http.Flusher).Flush()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	d := newTestDownloader()
+
+	probed, err := d.Probe(context.Background(), server.URL+"/etag.pdf", 0)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if probed.MIME != "application/pdf" || probed.Size != int64(len(pdf)) || probed.Validator != `"v1"` {
+		t.Errorf("Probe() = %+v, want application/pdf, %d bytes, validator \"v1\"", probed, len(pdf))
+	}
+	if served.Load() > 512 {
+		t.Errorf("Probe() read %d bytes, want at most the sniffed 512", served.Load())
+	}
+
+	probed, err = d.Probe(context.Background(), server.URL+"/chunked.pdf", 0)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if probed.Size != 100 || probed.Validator != "" {
+		t.Errorf("Probe() = %+v, want the size of the short body and no validator", probed)
+	}
+
+	_, err = d.Probe(context.Background(), server.URL+"/etag.pdf", 1000)
+	var sizeErr *SizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Size != int64(len(pdf)) {
+		t.Errorf("Probe() error = %v, want a *SizeError with the announced size", err)
+	}
+}
+
+func TestFetchValidator(t *testing.T) {
+	etag := `"v1"`
+	modTime := time.Date(2025, 7, 30, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag.pdf":
+			w.Header().Set("ETag", etag)
+			http.ServeContent(w, r, "etag.pdf", time.Time{}, bytes.NewReader(pdf))
+		case "/modified.pdf":
+			http.ServeContent(w, r, "modified.pdf", modTime, bytes.NewReader(pdf))
+		case "/ignoring.pdf":
+			// Ignores preconditions but reports the current version.
+			w.Header().Set("ETag", etag)
+			w.Write(pdf)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	d := newTestDownloader()
+	tests := []struct {
+		name      string
+		path      string
+		validator string
+		changed   bool
+	}{
+		{"no validator", "/etag.pdf", "", false},
+		{"same etag", "/etag.pdf", `"v1"`, false},
+		{"other etag", "/etag.pdf", `"v0"`, true},
+		{"not modified", "/modified.pdf", modTime.Format(http.TimeFormat), false},
+		{"modified", "/modified.pdf", modTime.Add(-time.Hour).Format(http.TimeFormat), true},
+		{"precondition ignored", "/ignoring.pdf", `"v0"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			download, err := d.Fetch(context.Background(), server.URL+tt.path, tt.validator, 0, func(mime string, size int64) (io.Writer, error) {
+				if mime != "application/pdf" || size != int64(len(pdf)) {
+					t.Errorf("open(%q, %d), want application/pdf, %d", mime, size, len(pdf))
+				}
+				return &buf, nil
+			})
+			if tt.changed {
+				if !errors.Is(err, ErrContentChanged) {
+					t.Errorf("Fetch() error = %v, want ErrContentChanged", err)
+				}
+				if buf.Len() > 0 {
+					t.Errorf("Fetch() wrote %d bytes of a changed file", buf.Len())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), pdf) || download.Size != int64(len(pdf)) {
+				t.Errorf("Fetch() wrote %d bytes, want the whole file", buf.Len())
+			}
+		})
+	}
+}
+
+func TestRangeTotal(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"bytes 0-511/4096", 4096, false},
+		{"bytes 0-99/100", 100, false},
+		{"bytes 0-511/*", -1, false},
+		{"bytes 10-511/4096", 0, true},
+		{"bytes 0-511/511", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := rangeTotal(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("rangeTotal(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newTestDownloader() *Downloader {
+	return NewDownloader(http.DefaultClient, 512, policy.New(nil, nil, nil, nil), RetryPolicy{MaxAttempts: 1}, 4)
+}
+
+// countingWriter counts the body bytes a handler writes.
+type countingWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *countingWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
+	"github.com/BabichevDima/2025-07-30-archive-service/pkg/safehttp"
+)
+
+var (
+	// ErrFileUnavailable is returned when a source URL does not answer with 200 OK.
+	ErrFileUnavailable = errors.New("file unavailable")
+	// ErrContentChanged is returned when a re-downloaded file differs from the
+	// validated one.
+	ErrContentChanged = errors.New("content changed since validation")
+	// ErrFileTooLarge is returned when a file exceeds the size limit.
+	ErrFileTooLarge = errors.New("file too large")
+)
+
+// ErrorCode classifies a download failure for reporting on the task item.
+func ErrorCode(err error) models.ItemErrorCode {
+	var rejected *policy.RejectedError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &rejected):
+		return models.ItemErrorTypeNotAllowed
+	case errors.Is(err, ErrFileTooLarge):
+		return models.ItemErrorTooLarge
+	case errors.Is(err, safehttp.ErrBlocked):
+		return models.ItemErrorBlocked
+	case errors.Is(err, ErrContentChanged):
+		return models.ItemErrorContentChanged
+	case errors.Is(err, ErrFileUnavailable):
+		return models.ItemErrorHTTPStatus
+	case errors.Is(err, context.Canceled):
+		return models.ItemErrorCancelled
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ItemErrorTimeout
+	case errors.As(err, &netErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return models.ItemErrorNetwork
+	default:
+		return models.ItemErrorUnknown
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+// Names of the entries describing the archive.
+const (
+	manifestName  = "manifest.json"
+	checksumsName = "SHA256SUMS"
+)
+
+// manifest is the content of manifest.json.
+type manifest struct {
+	TaskID      string           `json:"task_id"`
+	Name        string           `json:"name"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Files       []manifestFile   `json:"files"`
+	Failed      []manifestFailed `json:"failed"`
+}
+
+// manifestFile describes an archived file and where it came from.
+type manifestFile struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	HTTPStatus  int       `json:"http_status"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// manifestFailed describes a file missing from the archive. Name is set if
+// the file failed mid-stream and its entry is incomplete.
+type manifestFailed struct {
+	URL     string `json:"url"`
+	Name    string `json:"name,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeManifest adds manifest.json and SHA256SUMS for the archived items.
+func writeManifest(writer archive.Writer, task *models.Task, items []models.TaskItem) error {
+	now := time.Now().UTC()
+	doc := manifest{
+		TaskID:      task.ID,
+		Name:        task.Name,
+		GeneratedAt: now,
+		Files:       []manifestFile{},
+		Failed:      []manifestFailed{},
+	}
+	var sums strings.Builder
+
+	for _, item := range items {
+		if item.State == models.ItemStateFailed {
+			doc.Failed = append(doc.Failed, manifestFailed{
+				URL:     item.URL,
+				Name:    item.EntryName,
+				Code:    string(item.ErrorCode),
+				Message: item.Error,
+			})
+			continue
+		}
+		if item.EntryName == "" {
+			continue
+		}
+
+		file := manifestFile{
+			Name:        item.EntryName,
+			URL:         item.URL,
+			ContentType: item.MIME,
+			Size:        item.Size,
+			SHA256:      item.SHA256,
+		}
+		if n := len(item.Attempts); n > 0 {
+			file.HTTPStatus = item.Attempts[n-1].StatusCode
+			file.FetchedAt = item.Attempts[n-1].At.UTC()
+		}
+		doc.Files = append(doc.Files, file)
+		sums.WriteString(checksumLine(item.SHA256, item.EntryName))
+	}
+
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := addEntry(writer, manifestName, append(raw, '\n'), now); err != nil {
+		return err
+	}
+	return addEntry(writer, checksumsName, []byte(sums.String()), now)
+}
+
+// checksumLine formats a line of sha256sum output. Names with a backslash or
+// newline are escaped the way GNU coreutils does.
+func checksumLine(sum, name string) string {
+	if !strings.ContainsAny(name, "\\\n") {
+		return sum + "  " + name + "\n"
+	}
+	name = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(name)
+	return `\` + sum + "  " + name + "\n"
+}
+
+func addEntry(writer archive.Writer, name string, content []byte, modTime time.Time) error {
+	entry, err := writer.Create(name, int64(len(content)), modTime)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(content)
+	return err
+}
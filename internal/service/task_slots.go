@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// taskSlots limits the concurrent downloads of each task, so a task with
+// many files does not take every slot of the Downloader.
+type taskSlots struct {
+	limit int
+
+	mu    sync.Mutex
+	tasks map[string]*slotGroup
+}
+
+// slotGroup holds the slots of one task and counts the downloads holding or
+// waiting for them.
+type slotGroup struct {
+	slots chan struct{}
+	users int
+}
+
+func newTaskSlots(limit int) *taskSlots {
+	return &taskSlots{limit: limit, tasks: map[string]*slotGroup{}}
+}
+
+// acquire waits for a slot of the task and returns the function releasing it.
+func (t *taskSlots) acquire(ctx context.Context, taskID string) (func(), error) {
+	t.mu.Lock()
+	group, ok := t.tasks[taskID]
+	if !ok {
+		group = &slotGroup{slots: make(chan struct{}, t.limit)}
+		t.tasks[taskID] = group
+	}
+	group.users++
+	t.mu.Unlock()
+
+	select {
+	case group.slots <- struct{}{}:
+		return func() {
+			<-group.slots
+			t.leave(taskID, group)
+		}, nil
+	case <-ctx.Done():
+		t.leave(taskID, group)
+		return nil, ctx.Err()
+	}
+}
+
+func (t *taskSlots) leave(taskID string, group *slotGroup) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	group.users--
+	if group.users == 0 {
+		delete(t.tasks, taskID)
+	}
+}
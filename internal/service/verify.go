@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+// Verification is the outcome of reading back an archive.
+type Verification struct {
+	// Size and SHA256 describe the file as it is on disk now.
+	Size   int64
+	SHA256 string
+	// Entries counts the file entries read from the archive.
+	Entries int
+	// Problems lists every way the archive differs from the one that was
+	// built; the archive is intact if it is empty.
+	Problems []string
+}
+
+func (s *ArchiveServiceImpl) VerifyArchive(ctx context.Context, task *models.Task, format archive.Format) (Verification, error) {
+	result := Verification{Problems: []string{}}
+
+	file, err := os.Open(task.ZipPath)
+	if os.IsNotExist(err) {
+		result.Problems = append(result.Problems, "archive file is missing")
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	digest := newDigestWriter(io.Discard)
+	if _, err := io.Copy(digest, file); err != nil {
+		return result, fmt.Errorf("failed to read archive: %w", err)
+	}
+	result.Size, result.SHA256 = digest.size, digest.Sum()
+
+	// Tasks completed by earlier versions have no recorded hash; only the
+	// structure of their archives can be checked.
+	if task.ArchiveSHA256 != "" {
+		if result.Size != task.ArchiveSize {
+			result.Problems = append(result.Problems, fmt.Sprintf("size is %d bytes, recorded %d", result.Size, task.ArchiveSize))
+		}
+		if result.SHA256 != task.ArchiveSHA256 {
+			result.Problems = append(result.Problems, fmt.Sprintf("sha256 is %s, recorded %s", result.SHA256, task.ArchiveSHA256))
+		}
+	}
+
+	// Every archived file must be present with the content it was
+	// validated with.
+	expected := map[string]string{}
+	var names []string
+	for _, item := range task.Items {
+		if item.State == models.ItemStateDone && item.EntryName != "" {
+			expected[item.EntryName] = item.SHA256
+			names = append(names, item.EntryName)
+		}
+	}
+	if task.Manifest {
+		for _, name := range []string{manifestName, checksumsName} {
+			expected[name] = ""
+			names = append(names, name)
+		}
+	}
+
+	seen := map[string]bool{}
+	err = archive.Walk(format, file, result.Size, func(name string, content io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result.Entries++
+
+		entry := newDigestWriter(io.Discard)
+		if _, err := io.Copy(entry, content); err != nil {
+			return err
+		}
+		want, ok := expected[name]
+		if !ok {
+			return nil
+		}
+		seen[name] = true
+		if sum := entry.Sum(); want != "" && sum != want {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: sha256 is %s, validated %s", name, sum, want))
+		}
+		return nil
+	})
+	if errors.Is(err, archive.ErrCorrupt) {
+		result.Problems = append(result.Problems, err.Error())
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to verify archive: %w", err)
+	}
+
+	for _, name := range names {
+		if !seen[name] {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s is missing", name))
+		}
+	}
+	return result, nil
+}
+
+// digestWriter hashes and counts what is written through it.
+type digestWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func newDigestWriter(w io.Writer) *digestWriter {
+	return &digestWriter{w: w, hash: sha256.New()}
+}
+
+func (d *digestWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	d.hash.Write(p[:n])
+	d.size += int64(n)
+	return n, err
+}
+
+// Sum returns the hex-encoded SHA-256 of everything written so far.
+func (d *digestWriter) Sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
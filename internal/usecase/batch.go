@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/service"
+)
+
+// Outcomes of the URLs of a batch request.
+const (
+	URLAccepted = "accepted"
+	URLRejected = "rejected"
+	URLSkipped  = "skipped"
+)
+
+// Codes of URLs rejected before anything is downloaded.
+const (
+	codeInvalidURL      = "invalid_url"
+	codeInvalidFilename = "invalid_filename"
+)
+
+// checkURLs validates the URLs and file names of a batch request without
+// downloading anything. If any of them is invalid, the others are reported
+// as skipped.
+func checkURLs(requests []dto.URLRequest) ([]dto.URLResult, bool) {
+	results := make([]dto.URLResult, len(requests))
+	ok := true
+	for i, request := range requests {
+		results[i] = dto.URLResult{URL: request.URL, Filename: request.Filename}
+
+		urlErr := checkURL(request.URL)
+		nameErr := validateFilename(request.Filename)
+		switch {
+		case urlErr != nil:
+			results[i].Status = URLRejected
+			results[i].Code = codeInvalidURL
+			results[i].Message = urlErr.Error()
+			ok = false
+		case nameErr != nil:
+			results[i].Status = URLRejected
+			results[i].Code = codeInvalidFilename
+			results[i].Message = nameErr.Error()
+			ok = false
+		}
+	}
+
+	for i := range results {
+		if results[i].Status == "" {
+			if ok {
+				results[i].Status = URLAccepted
+			} else {
+				results[i].Status = URLSkipped
+			}
+		}
+	}
+	return results, ok
+}
+
+// checkURL accepts absolute URLs. Schemes and hosts are checked when the
+// file is downloaded.
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w %q: must be absolute", ErrInvalidURL, rawURL)
+	}
+	return nil
+}
+
+// validateFilename accepts empty names and valid archive entry names.
+func validateFilename(name string) error {
+	if name == "" {
+		return nil
+	}
+	if err := archive.CheckName(name); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFilename, err)
+	}
+	return nil
+}
+
+// addURLs adds the files of a batch request to the task and validates them
+// in the background like AddURL does.
+func (u *TaskUsecase) addURLs(ctx context.Context, taskID string, requests []dto.URLRequest) ([]dto.URLResult, error) {
+	results := make([]dto.URLResult, len(requests))
+	for i, request := range requests {
+		if !u.beginValidation(taskID) {
+			return nil, fmt.Errorf("failed to add URL: %w", repository.ErrTaskClosed)
+		}
+		item := models.TaskItem{URL: request.URL, State: models.ItemStatePending, Filename: request.Filename}
+		index, err := u.repo.AddItem(ctx, taskID, item)
+		if err != nil {
+			u.endValidation(taskID)
+			return nil, fmt.Errorf("failed to add URL: %w", err)
+		}
+		go u.validateItem(taskID, index, item)
+
+		results[i] = dto.URLResult{URL: request.URL, Filename: request.Filename, Status: URLAccepted}
+	}
+	return results, nil
+}
+
+func urlErrorCode(err error) models.ItemErrorCode {
+	if errors.Is(err, repository.ErrSizeLimitReached) {
+		return models.ItemErrorTooLarge
+	}
+	return service.ErrorCode(err)
+}
+
+// discardTask removes a task whose batch request could not be completed.
+func (u *TaskUsecase) discardTask(taskID string) {
+	u.release(taskID)
+
+	if err := u.archiveSvc.Remove(taskID); err != nil {
+		log.Printf("Failed to remove files of rejected task %s: %v", taskID, err)
+	}
+	if err := u.repo.Delete(context.Background(), taskID); err != nil {
+		log.Printf("Failed to delete rejected task %s: %v", taskID, err)
+	}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+)
+
+var (
+	// ErrServerBusy is returned when the maximum number of active tasks is reached.
+	ErrServerBusy = errors.New("server is busy")
+	// ErrArchiveNotReady is returned when the archive of a task has not been built yet.
+	ErrArchiveNotReady = errors.New("archive is not ready")
+	// ErrInvalidQuery is returned for unknown sort options, statuses or cursors.
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrTaskEmpty is returned when a task without files is finalized.
+	ErrTaskEmpty = errors.New("task has no files")
+	// ErrInvalidURL is returned for URLs that are not absolute.
+	ErrInvalidURL = errors.New("invalid URL")
+	// ErrInvalidFilename is returned for file names that are not a single
+	// path element.
+	ErrInvalidFilename = errors.New("invalid file name")
+	// ErrEphemeralTask is returned when an ephemeral task is finalized or
+	// its stored archive is requested.
+	ErrEphemeralTask = errors.New("ephemeral tasks are only streamed")
+	// ErrBatchRejected is wrapped by BatchError.
+	ErrBatchRejected = errors.New("some URLs were rejected")
+)
+
+// BatchError is returned when a task is not created because some of the
+// URLs in the request were rejected.
+type BatchError struct {
+	Results []dto.URLResult
+}
+
+func (e *BatchError) Error() string {
+	for _, result := range e.Results {
+		if result.Status == URLRejected {
+			return fmt.Sprintf("%v: %s: %s", ErrBatchRejected, result.URL, result.Message)
+		}
+	}
+	return ErrBatchRejected.Error()
+}
+
+func (e *BatchError) Unwrap() error {
+	return ErrBatchRejected
+}
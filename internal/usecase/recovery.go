@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+// RecoverySummary describes what Recover found in the repository.
+type RecoverySummary struct {
+	// Pending is the number of unfinished tasks still collecting URLs.
+	Pending int
+	// Requeued is the number of tasks put back into the archiving queue.
+	Requeued int
+	// Interrupted is the number of requeued tasks that were being archived
+	// when the previous process stopped.
+	Interrupted int
+	// CleanedUp is the number of interrupted tasks whose partial files were
+	// removed.
+	CleanedUp int
+	// Revalidated is the number of interrupted URL validations resumed.
+	Revalidated int
+	// Orphans is the number of tasks whose leftover files were removed
+	// because the task is unknown, failed or cancelled.
+	Orphans int
+}
+
+// Recover restores the in-memory state after a restart: unfinished tasks are
+// tracked again, interrupted URL validations are resumed, and tasks that were
+// queued or being archived are cleaned up and queued again in their original
+// order. It must be called
+// before the usecase starts serving requests. Files in storage that no task
+// needs anymore are removed.
+func (u *TaskUsecase) Recover(ctx context.Context) (RecoverySummary, error) {
+	summary := RecoverySummary{}
+
+	tasks, err := u.repo.GetAllTasks(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].UpdatedAt.Before(tasks[j].UpdatedAt)
+	})
+
+	for _, task := range tasks {
+		if task.Status.IsTerminal() {
+			continue
+		}
+
+		u.mu.Lock()
+		u.track(task.ID)
+		u.mu.Unlock()
+
+		if task.Status.AcceptsItems() {
+			summary.Pending++
+			summary.Revalidated += u.revalidate(task)
+			if len(task.Items) > 0 && !task.Ephemeral {
+				u.resetIdle(task.ID, task.UpdatedAt)
+			}
+			continue
+		}
+
+		// Tasks queued before the restart keep their slot even if the
+		// limit was lowered meanwhile.
+		u.setQueued(task.ID, true, false)
+		summary.Requeued++
+
+		if task.Status == models.StatusQueued {
+			// Files whose validation was interrupted are validated again
+			// before the task is archived.
+			summary.Revalidated += u.requeue(task)
+			continue
+		}
+
+		summary.Interrupted++
+		if err := u.archiveSvc.Cleanup(task.ID); err != nil {
+			return summary, fmt.Errorf("failed to clean up task %s: %w", task.ID, err)
+		}
+		summary.CleanedUp++
+		// The archive is built again from scratch.
+		for i, item := range task.Items {
+			if item.State != models.ItemStateDownloading && item.State != models.ItemStateDone {
+				continue
+			}
+			item.State = models.ItemStateValidated
+			item.EntryName = ""
+			if err := u.repo.UpdateItem(ctx, task.ID, i, item); err != nil {
+				return summary, fmt.Errorf("failed to reset task %s: %w", task.ID, err)
+			}
+		}
+		if err := u.repo.UpdateTaskStatus(ctx, task.ID, models.StatusQueued); err != nil {
+			return summary, fmt.Errorf("failed to queue task: %w", err)
+		}
+		if err := u.schedule(task.ID); err != nil {
+			return summary, err
+		}
+	}
+
+	orphans, err := u.removeOrphans(tasks)
+	if err != nil {
+		return summary, fmt.Errorf("failed to remove orphaned files: %w", err)
+	}
+	summary.Orphans = orphans
+
+	return summary, nil
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+)
+
+// expiresAt returns when a finished task is due for removal. Unfinished
+// tasks and statuses kept forever never expire.
+func (u *TaskUsecase) expiresAt(task *models.Task) (time.Time, bool) {
+	if !task.Status.IsTerminal() {
+		return time.Time{}, false
+	}
+	ttl := u.retention[task.Status]
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return task.UpdatedAt.Add(ttl), true
+}
+
+// Sweep deletes expired tasks together with their files and returns how
+// many were removed.
+func (u *TaskUsecase) Sweep(ctx context.Context) (int, error) {
+	tasks, err := u.repo.GetAllTasks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	now := time.Now()
+	removed := 0
+	for _, task := range tasks {
+		expiresAt, ok := u.expiresAt(task)
+		if !ok || now.Before(expiresAt) {
+			continue
+		}
+
+		if err := u.archiveSvc.Remove(task.ID); err != nil {
+			return removed, fmt.Errorf("failed to remove files of task %s: %w", task.ID, err)
+		}
+		if err := u.repo.Delete(ctx, task.ID); err != nil && !errors.Is(err, repository.ErrTaskNotFound) {
+			return removed, fmt.Errorf("failed to delete task %s: %w", task.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// sweepLoop runs Sweep every interval until ctx is canceled.
+func (u *TaskUsecase) sweepLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		removed, err := u.Sweep(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Retention sweep failed: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("Retention sweep removed %d expired tasks", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// removeOrphans deletes files in storage that no task needs anymore:
+// archives and payloads of unknown, failed or cancelled tasks, and payloads
+// left behind by completed ones. It returns the number of tasks whose files
+// were removed completely.
+func (u *TaskUsecase) removeOrphans(tasks []*models.Task) (int, error) {
+	byID := make(map[string]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	ids, err := u.archiveSvc.StoredTaskIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, id := range ids {
+		task, ok := byID[id]
+		switch {
+		case ok && !task.Status.IsTerminal():
+			continue
+		case ok && task.Status == models.StatusCompleted:
+			if err := u.archiveSvc.RemoveTemp(id); err != nil {
+				return removed, err
+			}
+		default:
+			if err := u.archiveSvc.Remove(id); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+// StreamSummary is the outcome of a streamed archive.
+type StreamSummary struct {
+	// Files counts the files written completely.
+	Files int
+	// Failed lists the files that are missing from the archive or were cut
+	// short while they were streamed.
+	Failed []dto.ItemError
+}
+
+// StreamArchive downloads the validated files of a task into an archive
+// written to the writer returned by start, without storing anything. start
+// is called once the task turned out to be ready, so errors returned before
+// that can still be reported to the client. A complete stream of an
+// ephemeral task completes the task.
+func (u *TaskUsecase) StreamArchive(ctx context.Context, taskID string, start func(ArchiveFile) io.Writer) (StreamSummary, error) {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return StreamSummary{}, fmt.Errorf("failed to stream archive: %w", err)
+	}
+	if task.Status == models.StatusCancelled {
+		return StreamSummary{}, fmt.Errorf("%w (status %q)", ErrArchiveNotReady, task.Status)
+	}
+	if validating := task.CountItems(models.ItemStatePending) + task.CountItems(models.ItemStateValidating); validating > 0 {
+		return StreamSummary{}, fmt.Errorf("%w (%d files are being validated)", ErrArchiveNotReady, validating)
+	}
+	if len(task.Items) == task.CountItems(models.ItemStateFailed) {
+		return StreamSummary{}, fmt.Errorf("failed to stream archive: %w", ErrTaskEmpty)
+	}
+	format, err := archive.ParseFormat(task.Format)
+	if err != nil {
+		return StreamSummary{}, fmt.Errorf("failed to stream archive: %w", err)
+	}
+
+	// Deleting the task aborts the stream.
+	ctx, cancel := u.taskContext(ctx, taskID)
+	defer cancel()
+
+	w := start(ArchiveFile{
+		Name:        archiveFileName(task, format),
+		ContentType: format.ContentType(),
+	})
+	items, err := u.archiveSvc.StreamArchive(ctx, task, format, w)
+
+	summary := StreamSummary{Failed: []dto.ItemError{}}
+	for _, item := range items {
+		switch item.State {
+		case models.ItemStateDone:
+			summary.Files++
+		case models.ItemStateFailed:
+			summary.Failed = append(summary.Failed, dto.ItemError{
+				URL:     item.URL,
+				Code:    string(item.ErrorCode),
+				Message: item.Error,
+			})
+		}
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to stream archive: %w", err)
+	}
+
+	if task.Ephemeral && task.Status.AcceptsItems() {
+		u.completeEphemeral(ctx, taskID, items)
+	}
+	return summary, nil
+}
+
+// completeEphemeral records the streamed items of an ephemeral task, marks
+// it as completed and stops tracking it. Files added while it was streamed
+// are not archived.
+func (u *TaskUsecase) completeEphemeral(ctx context.Context, taskID string, items []models.TaskItem) {
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil || !task.Status.AcceptsItems() {
+		return
+	}
+	for i, item := range items {
+		if err := u.repo.UpdateItem(ctx, taskID, i, item); err != nil {
+			log.Printf("Failed to record %s of ephemeral task %s: %v", item.URL, taskID, err)
+		}
+	}
+	if err := u.repo.UpdateTaskStatus(ctx, taskID, models.StatusCompleted); err != nil {
+		log.Printf("Failed to complete ephemeral task %s: %v", taskID, err)
+		return
+	}
+	u.release(taskID)
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+const (
+	// DefaultListLimit is the page size used when none is requested.
+	DefaultListLimit = 20
+	// MaxListLimit is the largest page size.
+	MaxListLimit = 100
+)
+
+var taskStatuses = []models.TaskStatus{
+	models.StatusCreated,
+	models.StatusInProcess,
+	models.StatusQueued,
+	models.StatusArchiving,
+	models.StatusCompleted,
+	models.StatusFailed,
+	models.StatusCancelled,
+}
+
+// listCursor identifies the last task of a page. It repeats the sort options
+// so that a cursor cannot be used with a different order.
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	ID    string    `json:"id"`
+	Name  string    `json:"n,omitempty"`
+	Time  time.Time `json:"t,omitempty"`
+}
+
+// ListTasks returns the page of tasks matching query. Tasks are ordered by
+// the sort field and then by ID, so pages stay stable while tasks are added.
+func (u *TaskUsecase) ListTasks(ctx context.Context, query dto.TaskListQuery) (dto.TaskListResponse, error) {
+	if query.Sort == "" {
+		query.Sort = "created_at"
+	}
+	if query.Order == "" {
+		query.Order = "desc"
+	}
+	switch {
+	case query.Sort != "created_at" && query.Sort != "updated_at" && query.Sort != "name":
+		return dto.TaskListResponse{}, fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, query.Sort)
+	case query.Order != "asc" && query.Order != "desc":
+		return dto.TaskListResponse{}, fmt.Errorf("%w: unknown order %q", ErrInvalidQuery, query.Order)
+	case query.Limit < 0 || query.Limit > MaxListLimit:
+		return dto.TaskListResponse{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxListLimit)
+	case query.Limit == 0:
+		query.Limit = DefaultListLimit
+	}
+
+	statuses, err := parseStatuses(query.Statuses)
+	if err != nil {
+		return dto.TaskListResponse{}, err
+	}
+
+	var after *models.Task
+	if query.Cursor != "" {
+		if after, err = decodeCursor(query.Cursor, query.Sort, query.Order); err != nil {
+			return dto.TaskListResponse{}, err
+		}
+	}
+
+	tasks, err := u.repo.GetAllTasks(ctx)
+	if err != nil {
+		return dto.TaskListResponse{}, err
+	}
+
+	matched := tasks[:0]
+	for _, task := range tasks {
+		if matchTask(task, query, statuses) {
+			matched = append(matched, task)
+		}
+	}
+
+	less := func(a, b *models.Task) bool {
+		c := compareTasks(a, b, query.Sort)
+		if query.Order == "desc" {
+			c = -c
+		}
+		return c < 0
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return less(matched[i], matched[j])
+	})
+
+	page := matched
+	if after != nil {
+		start := sort.Search(len(page), func(i int) bool {
+			return less(after, page[i])
+		})
+		page = page[start:]
+	}
+
+	resp := dto.TaskListResponse{
+		Tasks: make([]dto.ResponseTask, 0, min(len(page), query.Limit)),
+		Total: len(matched),
+	}
+	if len(page) > query.Limit {
+		page = page[:query.Limit]
+		resp.NextCursor = encodeCursor(page[len(page)-1], query.Sort, query.Order)
+	}
+	for _, task := range page {
+		resp.Tasks = append(resp.Tasks, u.toResponseTask(task))
+	}
+	return resp, nil
+}
+
+func parseStatuses(values []string) (map[models.TaskStatus]bool, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	statuses := make(map[models.TaskStatus]bool, len(values))
+	for _, value := range values {
+		found := false
+		for _, status := range taskStatuses {
+			if strings.EqualFold(value, string(status)) {
+				statuses[status] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, value)
+		}
+	}
+	return statuses, nil
+}
+
+func matchTask(task *models.Task, query dto.TaskListQuery, statuses map[models.TaskStatus]bool) bool {
+	switch {
+	case statuses != nil && !statuses[task.Status]:
+		return false
+	case query.Name != "" && !strings.Contains(strings.ToLower(task.Name), strings.ToLower(query.Name)):
+		return false
+	case !query.CreatedAfter.IsZero() && task.CreatedAt.Before(query.CreatedAfter):
+		return false
+	case !query.CreatedBefore.IsZero() && !task.CreatedAt.Before(query.CreatedBefore):
+		return false
+	case !query.UpdatedAfter.IsZero() && task.UpdatedAt.Before(query.UpdatedAfter):
+		return false
+	case !query.UpdatedBefore.IsZero() && !task.UpdatedAt.Before(query.UpdatedBefore):
+		return false
+	}
+	return true
+}
+
+// compareTasks orders tasks in ascending order of field, breaking ties by ID.
+func compareTasks(a, b *models.Task, field string) int {
+	var c int
+	switch field {
+	case "name":
+		c = strings.Compare(a.Name, b.Name)
+	case "updated_at":
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+func encodeCursor(task *models.Task, field, order string) string {
+	cursor := listCursor{Sort: field, Order: order, ID: task.ID}
+	switch field {
+	case "name":
+		cursor.Name = task.Name
+	case "updated_at":
+		cursor.Time = task.UpdatedAt
+	default:
+		cursor.Time = task.CreatedAt
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns a task holding the sort key stored in the cursor.
+func decodeCursor(value, field, order string) (*models.Task, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if cursor.Sort != field || cursor.Order != order {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort order", ErrInvalidQuery)
+	}
+
+	return &models.Task{
+		ID:        cursor.ID,
+		Name:      cursor.Name,
+		CreatedAt: cursor.Time,
+		UpdatedAt: cursor.Time,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	task := &models.Task{
+		ID:        "7f9c",
+		Name:      "Отчеты за июль",
+		CreatedAt: time.Date(2025, 7, 30, 10, 0, 0, 123456789, time.UTC),
+		UpdatedAt: time.Date(2025, 7, 31, 11, 30, 0, 987654321, time.UTC),
+	}
+
+	for _, field := range []string{"created_at", "updated_at", "name"} {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(field+" "+order, func(t *testing.T) {
+				got, err := decodeCursor(encodeCursor(task, field, order), field, order)
+				if err != nil {
+					t.Fatalf("decodeCursor() error = %v", err)
+				}
+				if c := compareTasks(got, task, field); c != 0 {
+					t.Errorf("decoded cursor %+v sorts %d against the task", got, c)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	task := &models.Task{ID: "7f9c", CreatedAt: time.Now()}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"not JSON", "bm90IGpzb24"},
+		{"no ID", encodeCursor(&models.Task{}, "created_at", "desc")},
+		{"other sort", encodeCursor(task, "name", "desc")},
+		{"other order", encodeCursor(task, "created_at", "asc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, "created_at", "desc")
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestListTasksPages(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewTaskRepository(3, 0)
+	u := &TaskUsecase{repo: repo}
+
+	// Names repeat so that ties are broken by ID.
+	for i := 0; i < 7; i++ {
+		if _, err := repo.Create(ctx, &models.Task{Name: fmt.Sprintf("task-%d", i%3)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, sortField := range []string{"created_at", "updated_at", "name"} {
+		for _, order := range []string{"asc", "desc"} {
+			t.Run(sortField+" "+order, func(t *testing.T) {
+				query := dto.TaskListQuery{Sort: sortField, Order: order, Limit: 3}
+				all, err := u.ListTasks(ctx, dto.TaskListQuery{Sort: sortField, Order: order, Limit: MaxListLimit})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var paged []string
+				for page := 0; ; page++ {
+					resp, err := u.ListTasks(ctx, query)
+					if err != nil {
+						t.Fatalf("page %d: ListTasks() error = %v", page, err)
+					}
+					if resp.Total != 7 {
+						t.Errorf("page %d: Total = %d, want 7", page, resp.Total)
+					}
+					for _, task := range resp.Tasks {
+						paged = append(paged, task.ID)
+					}
+					if resp.NextCursor == "" {
+						break
+					}
+					query.Cursor = resp.NextCursor
+				}
+
+				if len(paged) != len(all.Tasks) {
+					t.Fatalf("pages returned %d tasks, want %d", len(paged), len(all.Tasks))
+				}
+				for i, task := range all.Tasks {
+					if paged[i] != task.ID {
+						t.Errorf("task %d = %s, want %s", i, paged[i], task.ID)
+					}
+				}
+			})
+		}
+	}
+}
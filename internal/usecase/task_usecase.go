@@ -1,128 +1,745 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/config"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/scheduler"
 	"github.com/BabichevDima/2025-07-30-archive-service/internal/service"
-	"github.com/gabriel-vasile/mimetype"
 )
 
 type TaskUsecase struct {
-	repo       *repository.TaskRepository
+	repo       repository.TaskStore
 	archiveSvc service.ArchiveService
+	scheduler  *scheduler.Scheduler
 	maxTasks   int
-	active     int
-	mu         sync.Mutex
+	maxFiles   int
+	maxSize    int64
+	policy     policy.Policy
+	// autoFinalize queues a task once it has maxFiles files.
+	autoFinalize bool
+	idleTimeout  time.Duration
+	// retention maps terminal statuses to how long tasks are kept.
+	retention     map[models.TaskStatus]time.Duration
+	sweepInterval time.Duration
+	stopSweep     context.CancelFunc
+	sweepDone     chan struct{}
+	// background is the parent context of URL validations; it is canceled
+	// on shutdown.
+	background     context.Context
+	stopBackground context.CancelFunc
+	validations    sync.WaitGroup
+	// active holds every task from creation until it reaches a terminal
+	// status. Only queued tasks occupy a capacity slot, so tasks that are
+	// never finalized cannot block the service. Canceling a task's context
+	// aborts its downloads and archiving.
+	active map[string]activeTask
+	mu     sync.Mutex
+	// statusMu serializes status changes that depend on the current status,
+	// so a task cannot be finalized twice or finalized while being cancelled.
+	statusMu sync.Mutex
 }
 
-func NewTaskUsecase(repo *repository.TaskRepository, archiveSvc service.ArchiveService, maxTasks int) *TaskUsecase {
-	return &TaskUsecase{
-		repo:       repo,
-		archiveSvc: archiveSvc,
-		maxTasks:   maxTasks,
+type activeTask struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// idle finalizes the task when it receives no files for a while.
+	idle *time.Timer
+	// validating counts URL validations in flight. A task finalized while
+	// it is positive is queued once it drops to zero.
+	validating int
+	waiting    bool
+	// queued is set once the task was handed to the archiving queue.
+	queued bool
+}
+
+// NewTaskUsecase creates the usecase and its archiving worker pool.
+// Call Start before serving requests and Shutdown on exit.
+func NewTaskUsecase(
+	repo repository.TaskStore,
+	archiveSvc service.ArchiveService,
+	tasksCfg config.TasksConfig,
+	retentionCfg config.RetentionConfig,
+	filePolicy policy.Policy,
+) *TaskUsecase {
+	u := &TaskUsecase{
+		repo:         repo,
+		archiveSvc:   archiveSvc,
+		maxTasks:     tasksCfg.MaxActive,
+		maxFiles:     tasksCfg.MaxFiles,
+		maxSize:      tasksCfg.MaxSize,
+		policy:       filePolicy,
+		autoFinalize: tasksCfg.AutoFinalize,
+		idleTimeout:  tasksCfg.IdleTimeout,
+		retention: map[models.TaskStatus]time.Duration{
+			models.StatusCompleted: retentionCfg.Completed,
+			models.StatusFailed:    retentionCfg.Failed,
+			models.StatusCancelled: retentionCfg.Cancelled,
+		},
+		sweepInterval: retentionCfg.SweepInterval,
+		active:        make(map[string]activeTask),
 	}
+	u.background, u.stopBackground = context.WithCancel(context.Background())
+	u.scheduler = scheduler.New(tasksCfg.Workers, u.runArchive)
+	return u
+}
+
+// Start launches the archiving workers and the retention sweeper.
+func (u *TaskUsecase) Start() {
+	u.scheduler.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	u.stopSweep = cancel
+	u.sweepDone = make(chan struct{})
+	go func() {
+		defer close(u.sweepDone)
+		u.sweepLoop(ctx, u.sweepInterval)
+	}()
 }
 
-func (u *TaskUsecase) Create(request dto.RequestTask) (dto.ResponseTask, error) {
+// Shutdown stops the sweeper, idle timers and URL validations and waits for
+// running archive jobs. Queued tasks keep their status in the repository and
+// interrupted validations are resumed by Recover.
+func (u *TaskUsecase) Shutdown(ctx context.Context) error {
+	if u.stopSweep != nil {
+		u.stopSweep()
+		<-u.sweepDone
+	}
+
 	u.mu.Lock()
-	defer u.mu.Unlock()
+	for _, task := range u.active {
+		if task.idle != nil {
+			task.idle.Stop()
+		}
+	}
+	u.mu.Unlock()
+
+	u.stopBackground()
+	validated := make(chan struct{})
+	go func() {
+		u.validations.Wait()
+		close(validated)
+	}()
+	select {
+	case <-validated:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
-	if u.active >= u.maxTasks {
-		return dto.ResponseTask{}, fmt.Errorf("server is busy (max %d tasks allowed)", u.maxTasks)
+	return u.scheduler.Stop(ctx)
+}
+
+// Create creates a task and adds the URLs of the request to it. The files
+// are validated in the background. If any URL or file name is malformed, no
+// task is created and a *BatchError describes the outcome of every URL.
+func (u *TaskUsecase) Create(ctx context.Context, request dto.RequestTask) (dto.CreateTaskResponse, error) {
+	if len(request.URLs) > u.maxFiles {
+		return dto.CreateTaskResponse{}, fmt.Errorf("failed to create task: %w", repository.ErrURLLimitReached)
+	}
+	if request.Finalize && len(request.URLs) == 0 {
+		return dto.CreateTaskResponse{}, fmt.Errorf("failed to create task: %w", ErrTaskEmpty)
+	}
+	if request.Finalize && request.Ephemeral {
+		return dto.CreateTaskResponse{}, fmt.Errorf("failed to create task: %w", ErrEphemeralTask)
+	}
+	format, err := archive.ParseFormat(request.Format)
+	if err != nil {
+		return dto.CreateTaskResponse{}, fmt.Errorf("failed to create task: %w", err)
+	}
+	if results, ok := checkURLs(request.URLs); !ok {
+		return dto.CreateTaskResponse{}, &BatchError{Results: results}
 	}
 
-	resp := &models.Task{
-		Name: request.Name,
+	task, err := u.createTask(ctx, &models.Task{
+		Name:      request.Name,
+		Format:    string(format),
+		Ephemeral: request.Ephemeral,
+		Manifest:  request.Manifest,
+	})
+	if err != nil {
+		return dto.CreateTaskResponse{}, err
+	}
+	if len(request.URLs) == 0 {
+		return dto.CreateTaskResponse{ResponseTask: u.toResponseTask(task)}, nil
 	}
 
-	taskResp, err := u.repo.Create(resp)
+	results, err := u.addURLs(ctx, task.ID, request.URLs)
 	if err != nil {
-		return dto.ResponseTask{}, err
+		u.discardTask(task.ID)
+		return dto.CreateTaskResponse{}, err
 	}
 
-	u.active++
-	return dto.ResponseTask{
-		ID:        taskResp.ID,
-		Name:      taskResp.Name,
-		Status:    string(taskResp.Status),
-		URLs:      taskResp.URLs,
-		Errors:    taskResp.Errors,
-		ZipPath:   taskResp.ZipPath,
-		CreatedAt: taskResp.CreatedAt,
-		UpdatedAt: taskResp.UpdatedAt,
-	}, nil
+	if request.Finalize {
+		finalized, err := u.finalize(ctx, task.ID)
+		if err != nil {
+			// The client asked for a queued task, so none is left behind.
+			u.discardTask(task.ID)
+			return dto.CreateTaskResponse{}, fmt.Errorf("failed to create task: %w", err)
+		}
+		task = finalized
+	} else if task, err = u.itemsAdded(ctx, task.ID); err != nil {
+		return dto.CreateTaskResponse{}, err
+	}
+
+	return dto.CreateTaskResponse{ResponseTask: u.toResponseTask(task), Results: results}, nil
 }
 
-func (u *TaskUsecase) GetAllTasks() ([]*models.Task, error) {
-	return u.repo.GetAllTasks()
+func (u *TaskUsecase) createTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err := u.checkCapacity(); err != nil {
+		return nil, err
+	}
+
+	taskResp, err := u.repo.Create(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	u.track(taskResp.ID)
+	return taskResp, nil
 }
 
-func (u *TaskUsecase) AddURL(taskID string, url string) error {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// AddURL adds a pending file to the task and validates it in the
+// background. The outcome is reported in the task's items.
+func (u *TaskUsecase) AddURL(ctx context.Context, taskID string, url string, filename string) (dto.ResponseItem, error) {
+	if err := checkURL(url); err != nil {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", err)
+	}
+	if err := validateFilename(filename); err != nil {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", err)
+	}
+
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", err)
+	}
+	if !task.Status.AcceptsItems() {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", repository.ErrTaskClosed)
+	}
+	if len(task.Items)-task.CountItems(models.ItemStateFailed) >= u.maxFiles {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", repository.ErrURLLimitReached)
+	}
+	if u.maxSize > 0 && task.Size() >= u.maxSize {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", repository.ErrSizeLimitReached)
 	}
 
-	respFileData, err := client.Get(url)
+	// The validation is counted before the item becomes visible, so that a
+	// concurrent finalize waits for it.
+	if !u.beginValidation(taskID) {
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", repository.ErrTaskClosed)
+	}
+	item := models.TaskItem{URL: url, State: models.ItemStatePending, Filename: filename}
+	index, err := u.repo.AddItem(ctx, taskID, item)
 	if err != nil {
-		return fmt.Errorf("failed to download file by path: %v", url)
+		u.endValidation(taskID)
+		return dto.ResponseItem{}, fmt.Errorf("failed to add URL: %w", err)
 	}
-	defer respFileData.Body.Close()
+	go u.validateItem(taskID, index, item)
 
-	if respFileData.StatusCode != http.StatusOK {
-		return fmt.Errorf("file unavailable (status %d): %s", respFileData.StatusCode, url)
+	if _, err := u.itemsAdded(ctx, taskID); err != nil {
+		return dto.ResponseItem{}, err
 	}
 
-	limitedReader := io.LimitReader(respFileData.Body, 512)
-	mime, err := mimetype.DetectReader(limitedReader)
+	resp := toResponseItems([]models.TaskItem{item})[0]
+	resp.Index = index
+	return resp, nil
+}
+
+// probeItem validates a file without downloading it. A file name requested
+// by the client is checked against the policy like the URL is.
+func (u *TaskUsecase) probeItem(ctx context.Context, taskID, url, filename string, maxBytes int64) (models.TaskItem, error) {
+	item, err := u.archiveSvc.ProbeItem(ctx, taskID, url, maxBytes)
 	if err != nil {
-		return fmt.Errorf("failed to detect file type: %v", err)
+		return models.TaskItem{}, err
+	}
+	if filename != "" {
+		if err := u.policy.CheckName(item.MIME, filename); err != nil {
+			return models.TaskItem{}, err
+		}
+		item.Filename = filename
 	}
+	return item, nil
+}
 
-	if mime.String() == "application/pdf" || mime.String() == "image/jpeg" {
-		if err := u.repo.AddURL(taskID, url); err != nil {
-			return fmt.Errorf("failed to add URL: %w", err)
+// itemsAdded finalizes a task that reached the file limit, or restarts its
+// idle timer otherwise, and returns the task. Ephemeral tasks are left open.
+func (u *TaskUsecase) itemsAdded(ctx context.Context, taskID string) (*models.Task, error) {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Ephemeral {
+		return task, nil
+	}
+
+	if u.autoFinalize && task.CountItems(models.ItemStateValidated) >= u.maxFiles {
+		finalized, err := u.finalize(ctx, taskID)
+		switch {
+		case errors.Is(err, repository.ErrTaskClosed):
+			return task, nil
+		case errors.Is(err, ErrServerBusy):
+			// The idle timer tries again once the queue has room.
+			u.resetIdle(taskID, time.Now())
+			return task, nil
 		}
+		return finalized, err
+	}
+	u.resetIdle(taskID, task.UpdatedAt)
 
-		task, err := u.repo.GetTask(taskID)
-		if err != nil {
-			return err
+	return task, nil
+}
+
+// Finalize closes a task to new files and queues it for archiving.
+func (u *TaskUsecase) Finalize(ctx context.Context, taskID string) (dto.ResponseTask, error) {
+	task, err := u.finalize(ctx, taskID)
+	if err != nil {
+		return dto.ResponseTask{}, fmt.Errorf("failed to finalize task: %w", err)
+	}
+	return u.toResponseTask(task), nil
+}
+
+func (u *TaskUsecase) finalize(ctx context.Context, taskID string) (*models.Task, error) {
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.Status.AcceptsItems() {
+		return nil, repository.ErrTaskClosed
+	}
+	if task.Ephemeral {
+		return nil, ErrEphemeralTask
+	}
+	if len(task.Items) == task.CountItems(models.ItemStateFailed) {
+		return nil, ErrTaskEmpty
+	}
+
+	if err := u.enqueue(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return u.repo.GetTaskByID(ctx, taskID)
+}
+
+// finalizeIdle is run by the idle timer of a task.
+func (u *TaskUsecase) finalizeIdle(taskID string) {
+	_, err := u.finalize(context.Background(), taskID)
+	switch {
+	case err == nil:
+		log.Printf("Task %s finalized after %v without new files", taskID, u.idleTimeout)
+	case errors.Is(err, repository.ErrTaskClosed), errors.Is(err, repository.ErrTaskNotFound), errors.Is(err, ErrTaskEmpty):
+	case errors.Is(err, ErrServerBusy):
+		u.resetIdle(taskID, time.Now())
+	default:
+		log.Printf("Failed to finalize idle task %s: %v", taskID, err)
+	}
+}
+
+// GetPolicy returns the effective file policy.
+func (u *TaskUsecase) GetPolicy() dto.FilePolicyResponse {
+	return dto.FilePolicyResponse{
+		AllowTypes:      u.policy.AllowTypes,
+		DenyTypes:       u.policy.DenyTypes,
+		AllowExtensions: u.policy.AllowExtensions,
+		DenyExtensions:  u.policy.DenyExtensions,
+	}
+}
+
+// checkCapacity fails with ErrServerBusy once maxTasks tasks are queued or
+// being archived. The caller must hold u.mu.
+func (u *TaskUsecase) checkCapacity() error {
+	queued := 0
+	for _, task := range u.active {
+		if task.queued {
+			queued++
 		}
+	}
+	if queued >= u.maxTasks {
+		return fmt.Errorf("%w (max %d tasks allowed)", ErrServerBusy, u.maxTasks)
+	}
+	return nil
+}
+
+// setQueued records whether the task occupies a capacity slot. With check
+// set, taking a slot fails with ErrServerBusy when none is free.
+func (u *TaskUsecase) setQueued(taskID string, queued, check bool) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
-		if len(task.URLs) == 3 {
-			go func() {
-				if err := u.archiveSvc.CreateArchive(taskID, task.URLs); err != nil {
-					log.Printf("Archive failed for task %s: %v", taskID, err)
-					u.repo.UpdateTaskStatus(taskID, models.StatusFailed)
-				} else {
-					u.active--
-				}
-			}()
+	task, ok := u.active[taskID]
+	if !ok || task.queued == queued {
+		return nil
+	}
+	if queued && check {
+		if err := u.checkCapacity(); err != nil {
+			return err
 		}
+	}
+	task.queued = queued
+	u.active[taskID] = task
+	return nil
+}
 
+// enqueue takes a capacity slot, marks the task as queued and hands it to
+// the scheduler, or leaves that to the last of its URL validations still in
+// flight. It fails with ErrServerBusy if the queue is full.
+func (u *TaskUsecase) enqueue(ctx context.Context, taskID string) error {
+	if err := u.setQueued(taskID, true, true); err != nil {
+		return err
+	}
+	if err := u.repo.UpdateTaskStatus(ctx, taskID, models.StatusQueued); err != nil {
+		u.setQueued(taskID, false, false)
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+	return u.schedule(taskID)
+}
+
+// schedule hands a queued task to the scheduler unless its validations are
+// still in flight.
+func (u *TaskUsecase) schedule(taskID string) error {
+	if u.deferQueue(taskID) {
 		return nil
 	}
+	if err := u.scheduler.Enqueue(taskID); err != nil {
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+	return nil
+}
+
+// runArchive is executed by the worker pool for every queued task.
+func (u *TaskUsecase) runArchive(ctx context.Context, taskID string) {
+	defer u.release(taskID)
+
+	// jobCtx is only canceled when a shutdown stops waiting for the job.
+	jobCtx := ctx
+	ctx, cancel := u.taskContext(ctx, taskID)
+	defer cancel()
+
+	task, err := u.changeStatus(ctx, taskID, models.StatusQueued, func() error {
+		return u.repo.UpdateTaskStatus(ctx, taskID, models.StatusArchiving)
+	})
+	if err != nil {
+		log.Printf("Archive skipped for task %s: %v", taskID, err)
+		return
+	}
+	if task.Status != models.StatusQueued {
+		log.Printf("Archive skipped for task %s: status %q", taskID, task.Status)
+		return
+	}
+
+	var built models.Archive
+	format, archiveErr := archive.ParseFormat(task.Format)
+	if archiveErr == nil {
+		built, archiveErr = u.archiveSvc.CreateArchive(ctx, task, format)
+	}
+
+	// Record the outcome even if the job was canceled.
+	ctx = context.WithoutCancel(ctx)
+	task, err = u.changeStatus(ctx, taskID, models.StatusArchiving, func() error {
+		switch {
+		case archiveErr == nil:
+			return u.repo.UpdateTask(ctx, taskID, built, models.StatusCompleted)
+		case jobCtx.Err() != nil:
+			// The task stays Archiving and Recover queues it again.
+			log.Printf("Archive interrupted for task %s by shutdown", taskID)
+			return nil
+		}
+		log.Printf("Archive failed for task %s: %v", taskID, archiveErr)
+		return u.repo.UpdateTaskStatus(ctx, taskID, models.StatusFailed)
+	})
+	switch {
+	case errors.Is(err, repository.ErrTaskNotFound), err == nil && task.Status == models.StatusCancelled:
+		// Delete could not remove files the job was still writing.
+		if err := u.archiveSvc.Remove(taskID); err != nil {
+			log.Printf("Failed to remove files of cancelled task %s: %v", taskID, err)
+		}
+	case err != nil:
+		log.Printf("Failed to record the outcome of task %s: %v", taskID, err)
+	}
+}
+
+// changeStatus runs update if the task still has status from, and returns
+// the task as it was before. Delete cancels tasks under the same lock, so an
+// update never overwrites a cancellation.
+func (u *TaskUsecase) changeStatus(ctx context.Context, taskID string, from models.TaskStatus, update func() error) (*models.Task, error) {
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil || task.Status != from {
+		return task, err
+	}
+	return task, update()
+}
 
-	if err := u.repo.AddURL(taskID, url); err != nil {
-		return fmt.Errorf("failed to add URL: %w", err)
+// Delete cancels an unfinished task or removes a finished one. A cancelled
+// task keeps its record with status Cancelled, its downloads are aborted and
+// its files removed; deleting it again removes the record as well.
+func (u *TaskUsecase) Delete(ctx context.Context, taskID string) error {
+	// The status is changed first so that running jobs notice the
+	// cancellation instead of reporting a failure.
+	task, err := u.cancelTask(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	if task.Status.IsTerminal() {
+		if err := u.archiveSvc.Remove(taskID); err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		if err := u.repo.Delete(ctx, taskID); err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		return nil
 	}
 
+	u.scheduler.Remove(taskID)
+	u.release(taskID)
+
+	if err := u.archiveSvc.Remove(taskID); err != nil {
+		return fmt.Errorf("failed to cancel task: %w", err)
+	}
 	return nil
 }
 
-func (uc *TaskUsecase) GetTaskStatus(taskID string) (dto.TaskStatusResponse, error) {
-	task, err := uc.repo.GetTaskByID(taskID)
+// cancelTask marks an unfinished task as cancelled and returns the task as
+// it was before.
+func (u *TaskUsecase) cancelTask(ctx context.Context, taskID string) (*models.Task, error) {
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil || task.Status.IsTerminal() {
+		return task, err
+	}
+	return task, u.repo.UpdateTaskStatus(ctx, taskID, models.StatusCancelled)
+}
+
+// track registers a new or recovered task. The caller must hold u.mu.
+func (u *TaskUsecase) track(taskID string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	u.active[taskID] = activeTask{ctx: ctx, cancel: cancel}
+}
+
+// taskContext returns a context that is also canceled when the task is
+// cancelled. Tasks no longer tracked get ctx unchanged.
+func (u *TaskUsecase) taskContext(ctx context.Context, taskID string) (context.Context, context.CancelFunc) {
+	u.mu.Lock()
+	task, ok := u.active[taskID]
+	u.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	if !ok {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(task.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// release forgets a task that reached a terminal status, freeing its
+// capacity slot, and aborts whatever the task is still doing. It is safe to
+// call more than once.
+func (u *TaskUsecase) release(taskID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if task, ok := u.active[taskID]; ok {
+		task.cancel()
+		if task.idle != nil {
+			task.idle.Stop()
+		}
+		delete(u.active, taskID)
+	}
+}
+
+// resetIdle restarts the idle timer of a task that last changed at since.
+func (u *TaskUsecase) resetIdle(taskID string, since time.Time) {
+	if u.idleTimeout <= 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	task, ok := u.active[taskID]
+	if !ok {
+		return
+	}
+	if task.idle != nil {
+		task.idle.Stop()
+	}
+	task.idle = time.AfterFunc(time.Until(since.Add(u.idleTimeout)), func() {
+		u.finalizeIdle(taskID)
+	})
+	u.active[taskID] = task
+}
+
+func (u *TaskUsecase) GetArchive(ctx context.Context, taskID string) (ArchiveFile, error) {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return ArchiveFile{}, fmt.Errorf("failed to get archive: %w", err)
+	}
+
+	if task.Ephemeral {
+		return ArchiveFile{}, fmt.Errorf("failed to get archive: %w", ErrEphemeralTask)
+	}
+	if task.Status != models.StatusCompleted || task.ZipPath == "" {
+		return ArchiveFile{}, fmt.Errorf("%w (status %q)", ErrArchiveNotReady, task.Status)
+	}
+
+	format, err := archive.ParseFormat(task.Format)
+	if err != nil {
+		return ArchiveFile{}, fmt.Errorf("failed to get archive: %w", err)
+	}
+
+	return ArchiveFile{
+		Path:        task.ZipPath,
+		Name:        archiveFileName(task, format),
+		ContentType: format.ContentType(),
+		SHA256:      task.ArchiveSHA256,
+	}, nil
+}
+
+func (uc *TaskUsecase) GetTaskStatus(ctx context.Context, taskID string) (dto.TaskStatusResponse, error) {
+	task, err := uc.repo.GetTaskByID(ctx, taskID)
 	if err != nil {
 		return dto.TaskStatusResponse{}, fmt.Errorf("failed to get task status: %w", err)
 	}
-	return dto.TaskStatusResponse{
+	resp := dto.TaskStatusResponse{
 		Status: string(task.Status),
-	}, nil
+		Progress: dto.TaskProgress{
+			FilesTotal: len(task.Items),
+		},
+		Errors: []dto.ItemError{},
+		Items:  toResponseItems(task.Items),
+	}
+	for _, item := range task.Items {
+		switch item.State {
+		case models.ItemStateDone:
+			resp.Progress.FilesDone++
+			resp.Progress.BytesDownloaded += item.Size
+		case models.ItemStateFailed:
+			resp.Progress.FilesFailed++
+			resp.Errors = append(resp.Errors, dto.ItemError{
+				URL:     item.URL,
+				Code:    string(item.ErrorCode),
+				Message: item.Error,
+			})
+		}
+	}
+	if task.Status == models.StatusQueued {
+		resp.QueuePosition, _ = uc.scheduler.Position(taskID)
+	}
+	if task.Status == models.StatusCompleted && task.ZipPath != "" {
+		resp.ArchiveURL = archiveURL(task.ID)
+		resp.ArchiveSize = task.ArchiveSize
+		resp.ArchiveSHA256 = task.ArchiveSHA256
+	}
+	if expiresAt, ok := uc.expiresAt(task); ok {
+		resp.ExpiresAt = &expiresAt
+	}
+	return resp, nil
+}
+
+func (u *TaskUsecase) GetTask(ctx context.Context, taskID string) (dto.ResponseTask, error) {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return dto.ResponseTask{}, fmt.Errorf("failed to get task: %w", err)
+	}
+	return u.toResponseTask(task), nil
+}
+
+// ArchiveFile describes a completed archive on disk and the name it should be
+// downloaded under.
+type ArchiveFile struct {
+	Path        string
+	Name        string
+	ContentType string
+	// SHA256 is the hex-encoded hash recorded when the archive was built,
+	// empty for archives built by earlier versions.
+	SHA256 string
+}
+
+func archiveFileName(task *models.Task, format archive.Format) string {
+	name := strings.TrimSpace(task.Name)
+	if name == "" {
+		name = task.ID
+	}
+	return name + format.Extension()
+}
+
+func archiveURL(taskID string) string {
+	return "/api/tasks/" + taskID + "/archive"
+}
+
+func (u *TaskUsecase) toResponseTask(task *models.Task) dto.ResponseTask {
+	// Tasks created before formats were selectable have none stored.
+	format, _ := archive.ParseFormat(task.Format)
+	resp := dto.ResponseTask{
+		ID:        task.ID,
+		Name:      task.Name,
+		Status:    string(task.Status),
+		Format:    string(format),
+		Ephemeral: task.Ephemeral,
+		Manifest:  task.Manifest,
+		Items:     toResponseItems(task.Items),
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.UpdatedAt,
+	}
+	if task.Status == models.StatusCompleted && task.ZipPath != "" {
+		resp.ArchiveURL = archiveURL(task.ID)
+		resp.ArchiveSize = task.ArchiveSize
+		resp.ArchiveSHA256 = task.ArchiveSHA256
+	}
+	if expiresAt, ok := u.expiresAt(task); ok {
+		resp.ExpiresAt = &expiresAt
+	}
+	return resp
+}
+
+func toResponseItems(items []models.TaskItem) []dto.ResponseItem {
+	resp := make([]dto.ResponseItem, 0, len(items))
+	for i, item := range items {
+		attempts := make([]dto.ResponseAttempt, 0, len(item.Attempts))
+		for _, attempt := range item.Attempts {
+			attempts = append(attempts, dto.ResponseAttempt{
+				At:         attempt.At,
+				StatusCode: attempt.StatusCode,
+				Error:      attempt.Error,
+				Transient:  attempt.Transient,
+			})
+		}
+
+		resp = append(resp, dto.ResponseItem{
+			Index:     i,
+			URL:       item.URL,
+			State:     string(item.State),
+			MIME:      item.MIME,
+			Size:      item.Size,
+			SHA256:    item.SHA256,
+			ErrorCode: string(item.ErrorCode),
+			Error:     item.Error,
+			Attempts:  attempts,
+			Filename:  item.Filename,
+			EntryName: item.EntryName,
+		})
+	}
+	return resp
 }
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/config"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/policy"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/service"
+)
+
+// fakeArchiver validates every URL without downloading anything. URLs
+// containing "slow" are validated once gate is closed, URLs containing
+// "broken" fail. Archived tasks are reported on archived.
+type fakeArchiver struct {
+	gate     chan struct{}
+	archived chan string
+}
+
+func newFakeArchiver() *fakeArchiver {
+	return &fakeArchiver{gate: make(chan struct{}), archived: make(chan string, 10)}
+}
+
+func (f *fakeArchiver) ProbeItem(ctx context.Context, taskID string, url string, maxBytes int64) (models.TaskItem, error) {
+	if strings.Contains(url, "slow") {
+		select {
+		case <-f.gate:
+		case <-ctx.Done():
+			return models.TaskItem{}, ctx.Err()
+		}
+	}
+	if strings.Contains(url, "broken") {
+		return models.TaskItem{}, service.ErrFileUnavailable
+	}
+	return models.TaskItem{URL: url, State: models.ItemStateValidated, MIME: "application/pdf", Size: 10}, nil
+}
+
+func (f *fakeArchiver) CreateArchive(ctx context.Context, task *models.Task, format archive.Format) (models.Archive, error) {
+	f.archived <- task.ID
+	return models.Archive{Path: task.ID + format.Extension(), Size: 10}, nil
+}
+
+func (f *fakeArchiver) StreamArchive(ctx context.Context, task *models.Task, format archive.Format, w io.Writer) ([]models.TaskItem, error) {
+	return task.Items, nil
+}
+
+func (f *fakeArchiver) VerifyArchive(ctx context.Context, task *models.Task, format archive.Format) (service.Verification, error) {
+	return service.Verification{}, nil
+}
+
+func (f *fakeArchiver) Cleanup(taskID string) error      { return nil }
+func (f *fakeArchiver) Remove(taskID string) error       { return nil }
+func (f *fakeArchiver) RemoveTemp(taskID string) error   { return nil }
+func (f *fakeArchiver) StoredTaskIDs() ([]string, error) { return nil, nil }
+
+func newTestUsecase(t *testing.T, repo repository.TaskStore, archiver service.ArchiveService) *TaskUsecase {
+	t.Helper()
+
+	u := NewTaskUsecase(repo, archiver,
+		config.TasksConfig{MaxActive: 2, MaxFiles: 5, Workers: 1},
+		config.RetentionConfig{},
+		policy.New(nil, nil, nil, nil),
+	)
+	u.scheduler.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		u.Shutdown(ctx)
+	})
+	return u
+}
+
+func createTask(t *testing.T, u *TaskUsecase, urls ...string) string {
+	t.Helper()
+
+	request := dto.RequestTask{Name: "docs"}
+	for _, url := range urls {
+		request.URLs = append(request.URLs, dto.URLRequest{URL: url})
+	}
+	resp, err := u.Create(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return resp.ID
+}
+
+// waitForTask polls the task until cond holds for it.
+func waitForTask(t *testing.T, repo repository.TaskStore, taskID string, cond func(*models.Task) bool) *models.Task {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		task, err := repo.GetTaskByID(context.Background(), taskID)
+		if err != nil {
+			t.Fatalf("GetTaskByID() error = %v", err)
+		}
+		if cond(task) {
+			return task
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task stuck with status %q and items %+v", task.Status, task.Items)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func hasStatus(status models.TaskStatus) func(*models.Task) bool {
+	return func(task *models.Task) bool { return task.Status == status }
+}
+
+func TestFinalizeWaitsForValidation(t *testing.T) {
+	repo := repository.NewTaskRepository(5, 0)
+	archiver := newFakeArchiver()
+	u := newTestUsecase(t, repo, archiver)
+
+	taskID := createTask(t, u, "https://example.com/a.pdf", "https://example.com/slow.pdf")
+	waitForTask(t, repo, taskID, func(task *models.Task) bool {
+		return task.Items[0].State == models.ItemStateValidated
+	})
+
+	resp, err := u.Finalize(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("Finalize() error = %v", err)
+	}
+	if resp.Status != string(models.StatusQueued) {
+		t.Errorf("Finalize() status = %q, want %q", resp.Status, models.StatusQueued)
+	}
+	if _, err := u.Finalize(context.Background(), taskID); !errors.Is(err, repository.ErrTaskClosed) {
+		t.Errorf("second Finalize() error = %v, want ErrTaskClosed", err)
+	}
+
+	select {
+	case <-archiver.archived:
+		t.Fatal("task archived while a file was being validated")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(archiver.gate)
+	if got := <-archiver.archived; got != taskID {
+		t.Errorf("archived task %s, want %s", got, taskID)
+	}
+	task := waitForTask(t, repo, taskID, hasStatus(models.StatusCompleted))
+	if n := task.CountItems(models.ItemStateValidated); n != 2 {
+		t.Errorf("%d items validated, want 2", n)
+	}
+}
+
+func TestFinalizeWithoutValidFiles(t *testing.T) {
+	repo := repository.NewTaskRepository(5, 0)
+	archiver := newFakeArchiver()
+	u := newTestUsecase(t, repo, archiver)
+
+	t.Run("validated before", func(t *testing.T) {
+		taskID := createTask(t, u, "https://example.com/broken.pdf")
+		waitForTask(t, repo, taskID, func(task *models.Task) bool {
+			return task.Items[0].State == models.ItemStateFailed
+		})
+
+		if _, err := u.Finalize(context.Background(), taskID); !errors.Is(err, ErrTaskEmpty) {
+			t.Errorf("Finalize() error = %v, want ErrTaskEmpty", err)
+		}
+	})
+
+	t.Run("validated after", func(t *testing.T) {
+		taskID := createTask(t, u, "https://example.com/slow-broken.pdf")
+		if _, err := u.Finalize(context.Background(), taskID); err != nil {
+			t.Fatalf("Finalize() error = %v", err)
+		}
+
+		close(archiver.gate)
+		task := waitForTask(t, repo, taskID, hasStatus(models.StatusFailed))
+		if code := task.Items[0].ErrorCode; code != models.ItemErrorHTTPStatus {
+			t.Errorf("item error code = %q, want %q", code, models.ItemErrorHTTPStatus)
+		}
+		select {
+		case id := <-archiver.archived:
+			t.Errorf("task %s archived without valid files", id)
+		default:
+		}
+	})
+}
+
+func TestDeleteDuringValidation(t *testing.T) {
+	repo := repository.NewTaskRepository(5, 0)
+	archiver := newFakeArchiver()
+	u := newTestUsecase(t, repo, archiver)
+
+	taskID := createTask(t, u, "https://example.com/slow.pdf")
+	if _, err := u.Finalize(context.Background(), taskID); err != nil {
+		t.Fatalf("Finalize() error = %v", err)
+	}
+	if err := u.Delete(context.Background(), taskID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	task := waitForTask(t, repo, taskID, func(task *models.Task) bool {
+		return task.Items[0].State == models.ItemStateFailed
+	})
+	if task.Status != models.StatusCancelled {
+		t.Errorf("status = %q, want %q", task.Status, models.StatusCancelled)
+	}
+	if code := task.Items[0].ErrorCode; code != models.ItemErrorCancelled {
+		t.Errorf("item error code = %q, want %q", code, models.ItemErrorCancelled)
+	}
+
+	u.validations.Wait()
+	select {
+	case <-archiver.archived:
+		t.Error("cancelled task was archived")
+	default:
+	}
+}
+
+func TestShutdownDuringValidation(t *testing.T) {
+	repo := repository.NewTaskRepository(5, 0)
+	archiver := newFakeArchiver()
+	u := newTestUsecase(t, repo, archiver)
+
+	taskID := createTask(t, u, "https://example.com/a.pdf", "https://example.com/slow.pdf")
+	waitForTask(t, repo, taskID, func(task *models.Task) bool {
+		return task.Items[0].State == models.ItemStateValidated
+	})
+	if _, err := u.Finalize(context.Background(), taskID); err != nil {
+		t.Fatalf("Finalize() error = %v", err)
+	}
+	if err := u.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	task := waitForTask(t, repo, taskID, hasStatus(models.StatusQueued))
+	if state := task.Items[1].State; state != models.ItemStatePending {
+		t.Errorf("interrupted item state = %q, want %q", state, models.ItemStatePending)
+	}
+	select {
+	case <-archiver.archived:
+		t.Fatal("task archived during shutdown")
+	default:
+	}
+
+	// The next process validates the interrupted file before archiving.
+	close(archiver.gate)
+	restarted := newTestUsecase(t, repo, archiver)
+	summary, err := restarted.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if summary.Requeued != 1 || summary.Revalidated != 1 {
+		t.Errorf("Recover() = %+v, want 1 requeued and 1 revalidated", summary)
+	}
+
+	if got := <-archiver.archived; got != taskID {
+		t.Errorf("archived task %s, want %s", got, taskID)
+	}
+	task = waitForTask(t, repo, taskID, hasStatus(models.StatusCompleted))
+	if n := task.CountItems(models.ItemStateValidated); n != 2 {
+		t.Errorf("%d items validated, want 2", n)
+	}
+}
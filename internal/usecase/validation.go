@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/repository"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/service"
+)
+
+// beginValidation counts a URL validation of the task. It fails if the task
+// is no longer tracked.
+func (u *TaskUsecase) beginValidation(taskID string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	task, ok := u.active[taskID]
+	if !ok {
+		return false
+	}
+	task.validating++
+	u.active[taskID] = task
+	u.validations.Add(1)
+	return true
+}
+
+// endValidation is called once a validation started by beginValidation is
+// over. The last one queues a task that was finalized in the meantime, or
+// fails it if none of its files passed.
+func (u *TaskUsecase) endValidation(taskID string) {
+	defer u.validations.Done()
+
+	u.mu.Lock()
+	task, ok := u.active[taskID]
+	queue := false
+	if ok {
+		task.validating--
+		queue = task.waiting && task.validating == 0
+		if queue {
+			task.waiting = false
+		}
+		u.active[taskID] = task
+	}
+	u.mu.Unlock()
+
+	if !queue {
+		return
+	}
+	if u.background.Err() != nil {
+		// Shutting down: the task stays Queued and Recover validates its
+		// interrupted files before queuing it.
+		return
+	}
+
+	if u.failEmpty(taskID) {
+		u.release(taskID)
+		return
+	}
+	if err := u.scheduler.Enqueue(taskID); err != nil {
+		log.Printf("Failed to queue task %s: %v", taskID, err)
+	}
+}
+
+// failEmpty marks a queued task without a single valid file as failed.
+func (u *TaskUsecase) failEmpty(taskID string) bool {
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	ctx := context.Background()
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil || task.Status != models.StatusQueued || task.CountItems(models.ItemStateValidated) > 0 {
+		return false
+	}
+
+	log.Printf("Task %s failed: none of its files passed validation", taskID)
+	if err := u.repo.UpdateTaskStatus(ctx, taskID, models.StatusFailed); err != nil {
+		log.Printf("Failed to mark task %s as failed: %v", taskID, err)
+	}
+	return true
+}
+
+// deferQueue reports whether the task has validations in flight, in which
+// case the last of them queues it.
+func (u *TaskUsecase) deferQueue(taskID string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	task, ok := u.active[taskID]
+	if !ok || task.validating == 0 {
+		return false
+	}
+	task.waiting = true
+	u.active[taskID] = task
+	return true
+}
+
+// validateItem probes the pending item at index and records the outcome.
+func (u *TaskUsecase) validateItem(taskID string, index int, item models.TaskItem) {
+	defer u.endValidation(taskID)
+
+	ctx, cancel := u.taskContext(u.background, taskID)
+	defer cancel()
+
+	item.State = models.ItemStateValidating
+	if err := u.repo.UpdateItem(ctx, taskID, index, item); err != nil {
+		log.Printf("Failed to validate %s of task %s: %v", item.URL, taskID, err)
+		return
+	}
+
+	err := u.probeAndCommit(ctx, taskID, index, item)
+	if err == nil {
+		if _, err := u.itemsAdded(ctx, taskID); err != nil {
+			log.Printf("Failed to finalize task %s: %v", taskID, err)
+		}
+		return
+	}
+
+	if errors.Is(err, context.Canceled) && u.background.Err() != nil {
+		// Interrupted by shutdown, Recover validates the item again.
+		item.State = models.ItemStatePending
+		if err := u.repo.UpdateItem(context.WithoutCancel(ctx), taskID, index, item); err != nil {
+			log.Printf("Failed to reset %s of task %s: %v", item.URL, taskID, err)
+		}
+		return
+	}
+
+	var fetchErr *service.FetchError
+	if errors.As(err, &fetchErr) {
+		item.Attempts = fetchErr.Attempts
+	}
+	item.State = models.ItemStateFailed
+	item.ErrorCode = urlErrorCode(err)
+	item.Error = err.Error()
+	if err := u.repo.UpdateItem(context.WithoutCancel(ctx), taskID, index, item); err != nil && !errors.Is(err, repository.ErrTaskNotFound) {
+		log.Printf("Failed to record validation of %s for task %s: %v", item.URL, taskID, err)
+	}
+}
+
+// probeAndCommit validates an item within the task's remaining size budget
+// and stores it unless the task was cancelled meanwhile.
+func (u *TaskUsecase) probeAndCommit(ctx context.Context, taskID string, index int, item models.TaskItem) error {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status == models.StatusCancelled {
+		// Delete released the task before its context could be watched.
+		return context.Canceled
+	}
+	var remaining int64
+	if u.maxSize > 0 {
+		remaining = u.maxSize - task.Size()
+		if remaining <= 0 {
+			return fmt.Errorf("%w (max %d bytes)", repository.ErrSizeLimitReached, u.maxSize)
+		}
+	}
+
+	probed, err := u.probeItem(ctx, taskID, item.URL, item.Filename, remaining)
+	if err != nil {
+		return err
+	}
+
+	// Concurrent validations share the size budget, so it is checked again
+	// together with the other committed items.
+	u.statusMu.Lock()
+	defer u.statusMu.Unlock()
+
+	task, err = u.repo.GetTaskByID(ctx, taskID)
+	if err == nil && task.Status == models.StatusCancelled {
+		err = context.Canceled
+	}
+	if err == nil && u.maxSize > 0 && task.Size()-task.Items[index].Size+probed.Size > u.maxSize {
+		err = fmt.Errorf("%w (max %d bytes)", repository.ErrSizeLimitReached, u.maxSize)
+	}
+	if err != nil {
+		return err
+	}
+	return u.repo.UpdateItem(ctx, taskID, index, probed)
+}
+
+// requeue resumes the validations of a recovered queued task and queues it
+// once they are over, or right away if there are none. It returns how many
+// validations were started.
+func (u *TaskUsecase) requeue(task *models.Task) int {
+	// The task counts as validating until all of its validations have
+	// started, so that the first one to finish does not queue it early.
+	if !u.beginValidation(task.ID) {
+		return 0
+	}
+	u.deferQueue(task.ID)
+	started := u.revalidate(task)
+	u.endValidation(task.ID)
+	return started
+}
+
+// revalidate resumes the validations of a recovered task and returns how
+// many were started.
+func (u *TaskUsecase) revalidate(task *models.Task) int {
+	started := 0
+	for i, item := range task.Items {
+		if item.State != models.ItemStatePending && item.State != models.ItemStateValidating {
+			continue
+		}
+		if !u.beginValidation(task.ID) {
+			break
+		}
+		go u.validateItem(task.ID, i, item)
+		started++
+	}
+	return started
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/archive"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/dto"
+	"github.com/BabichevDima/2025-07-30-archive-service/internal/models"
+)
+
+// Verify reads back the archive of a completed task and checks it against
+// the size and hash recorded when it was built, the checksums of its format
+// and the validated content of every file.
+func (u *TaskUsecase) Verify(ctx context.Context, taskID string) (dto.VerifyResponse, error) {
+	task, err := u.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return dto.VerifyResponse{}, fmt.Errorf("failed to verify archive: %w", err)
+	}
+
+	if task.Ephemeral {
+		return dto.VerifyResponse{}, fmt.Errorf("failed to verify archive: %w", ErrEphemeralTask)
+	}
+	if task.Status != models.StatusCompleted || task.ZipPath == "" {
+		return dto.VerifyResponse{}, fmt.Errorf("%w (status %q)", ErrArchiveNotReady, task.Status)
+	}
+
+	format, err := archive.ParseFormat(task.Format)
+	if err != nil {
+		return dto.VerifyResponse{}, fmt.Errorf("failed to verify archive: %w", err)
+	}
+
+	result, err := u.archiveSvc.VerifyArchive(ctx, task, format)
+	if err != nil {
+		return dto.VerifyResponse{}, fmt.Errorf("failed to verify archive: %w", err)
+	}
+	if len(result.Problems) > 0 {
+		log.Printf("Archive of task %s is corrupt: %v", taskID, result.Problems)
+	}
+
+	return dto.VerifyResponse{
+		OK:             len(result.Problems) == 0,
+		Size:           result.Size,
+		SHA256:         result.SHA256,
+		ExpectedSize:   task.ArchiveSize,
+		ExpectedSHA256: task.ArchiveSHA256,
+		Entries:        result.Entries,
+		Problems:       result.Problems,
+	}, nil
+}
@@ -0,0 +1,252 @@
+// Package safehttp provides an HTTP client for fetching user-supplied URLs.
+//
+// Requests are only sent to allowed schemes, and connections are only made
+// to public addresses: loopback, private, link-local and other special-purpose
+// ranges are refused. Addresses are checked twice, once when the request is
+// issued and again for the exact IP being dialed, so a DNS answer that
+// changes between the two (DNS rebinding) cannot reach an internal service.
+// Redirects go through the same checks. Trusted hosts, given as host names,
+// "*.domain" wildcards, IPs or CIDR ranges, are exempt from the address
+// checks.
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is wrapped by every error caused by a refused destination.
+var ErrBlocked = errors.New("destination is not allowed")
+
+// BlockedError describes why a destination was refused.
+type BlockedError struct {
+	// Target is the URL, host or address that was refused.
+	Target string
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrBlocked, e.Target, e.Reason)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// blockedPrefixes are special-purpose ranges that are never reachable unless
+// trusted explicitly. Loopback, private, link-local, multicast and
+// unspecified addresses are handled by netip.Addr methods.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, may map to internal IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// Guard decides which destinations may be contacted.
+type Guard struct {
+	schemes  []string
+	hosts    []string
+	prefixes []netip.Prefix
+	resolver *net.Resolver
+}
+
+// NewGuard allows requests with the given schemes to public addresses and
+// to trustedHosts.
+func NewGuard(schemes, trustedHosts []string) (*Guard, error) {
+	g := &Guard{resolver: net.DefaultResolver}
+
+	for _, scheme := range schemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme != "http" && scheme != "https" {
+			return nil, fmt.Errorf("unsupported scheme %q", scheme)
+		}
+		g.schemes = append(g.schemes, scheme)
+	}
+	if len(g.schemes) == 0 {
+		return nil, errors.New("at least one scheme must be allowed")
+	}
+
+	for _, host := range trustedHosts {
+		host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+		if prefix, err := netip.ParsePrefix(host); err == nil {
+			g.prefixes = append(g.prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(host); err == nil {
+			g.prefixes = append(g.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.ContainsAny(name, "/:*") {
+			return nil, fmt.Errorf("invalid trusted host %q", host)
+		}
+		g.hosts = append(g.hosts, host)
+	}
+
+	return g, nil
+}
+
+// Client returns an HTTP client that enforces the guard on every request,
+// redirect and connection. Proxies from the environment are ignored, since
+// the proxy address would be checked instead of the destination.
+func (g *Guard) Client(timeout time.Duration, maxRedirects int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.dialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &guardedTransport{guard: g, next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// CheckURL validates the scheme of u and resolves its host, refusing it if
+// any of the addresses is not allowed.
+func (g *Guard) CheckURL(ctx context.Context, u *url.URL) error {
+	if !contains(g.schemes, strings.ToLower(u.Scheme)) {
+		return &BlockedError{Target: u.Redacted(), Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return &BlockedError{Target: u.Redacted(), Reason: "host is empty"}
+	}
+	if g.trustedHost(host) {
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddr(addr)
+	}
+
+	addrs, err := g.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := g.checkAddr(addr); err != nil {
+			return &BlockedError{Target: host, Reason: fmt.Sprintf("resolves to %v", addr.Unmap())}
+		}
+	}
+	return nil
+}
+
+func (g *Guard) trustedHost(host string) bool {
+	for _, trusted := range g.hosts {
+		if suffix, ok := strings.CutPrefix(trusted, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == trusted {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Guard) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range g.prefixes {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	if reason := specialPurpose(addr); reason != "" {
+		return &BlockedError{Target: addr.String(), Reason: reason}
+	}
+	return nil
+}
+
+func specialPurpose(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return "loopback address"
+	case addr.IsPrivate():
+		return "private address"
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return "link-local address"
+	case addr.IsUnspecified():
+		return "unspecified address"
+	case addr.IsMulticast():
+		return "multicast address"
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return "reserved address"
+		}
+	}
+	return ""
+}
+
+// dialContext checks the address actually being connected to. Connections
+// to trusted host names skip the check.
+func (g *Guard) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if !g.trustedHost(strings.TrimSuffix(strings.ToLower(host), ".")) {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return g.checkAddr(addrPort.Addr())
+		}
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
+
+// guardedTransport checks every outgoing request, including redirects,
+// before it is sent.
+type guardedTransport struct {
+	guard *Guard
+	next  http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.CheckURL(req.Context(), req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
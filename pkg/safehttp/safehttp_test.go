@@ -0,0 +1,183 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		schemes []string
+		trusted []string
+		wantErr bool
+	}{
+		{"defaults", []string{"http", "https"}, nil, false},
+		{"trusted hosts", []string{"HTTPS"}, []string{"files.example.com", "*.example.org", "10.0.0.1", "10.1.0.0/16", "::1"}, false},
+		{"no schemes", nil, nil, true},
+		{"unsupported scheme", []string{"ftp"}, nil, true},
+		{"bare wildcard", []string{"http"}, []string{"*."}, true},
+		{"host with port", []string{"http"}, []string{"example.com:8080"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGuard(tt.schemes, tt.trusted)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewGuard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckAddr(t *testing.T) {
+	g := mustGuard(t, "10.1.0.0/16", "192.168.5.5")
+
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"93.184.216.34", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"fc00::1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"100.64.0.1", true},
+		{"198.18.0.1", true},
+		{"255.255.255.255", true},
+		{"64:ff9b::7f00:1", true},
+		{"2001:db8::1", true},
+		{"10.1.2.3", false},
+		{"::ffff:10.1.2.3", false},
+		{"192.168.5.5", false},
+		{"192.168.5.6", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := g.checkAddr(netip.MustParseAddr(tt.addr))
+			if (err != nil) != tt.blocked {
+				t.Fatalf("checkAddr(%s) error = %v, blocked %v", tt.addr, err, tt.blocked)
+			}
+			if err != nil && !errors.Is(err, ErrBlocked) {
+				t.Errorf("checkAddr(%s) error = %v, want ErrBlocked", tt.addr, err)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	g := mustGuard(t, "*.internal.test", "trusted.test")
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"ftp://93.184.216.34/a.pdf", true},
+		{"http:///a.pdf", true},
+		{"http://127.0.0.1/a.pdf", true},
+		{"http://[::1]:8080/a.pdf", true},
+		{"http://localhost/a.pdf", true},
+		{"https://93.184.216.34/a.pdf", false},
+		{"http://trusted.test/a.pdf", false},
+		{"http://TRUSTED.test./a.pdf", false},
+		{"http://files.internal.test/a.pdf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = g.CheckURL(context.Background(), u)
+			if (err != nil) != tt.blocked {
+				t.Fatalf("CheckURL() error = %v, blocked %v", err, tt.blocked)
+			}
+			if err != nil && !errors.Is(err, ErrBlocked) {
+				t.Errorf("CheckURL() error = %v, want ErrBlocked", err)
+			}
+		})
+	}
+}
+
+// TestDialChecksAddress covers the second check, which catches host names
+// that resolve to an internal address only when the connection is made.
+func TestDialChecksAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := mustGuard(t)
+	conn, err := g.dialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	if err == nil {
+		conn.Close()
+		t.Fatal("dialContext() to localhost succeeded, want it blocked")
+	}
+	if !errors.Is(err, ErrBlocked) {
+		t.Errorf("dialContext() error = %v, want ErrBlocked", err)
+	}
+
+	trusted := mustGuard(t, "127.0.0.0/8", "::1")
+	conn, err = trusted.dialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	if err != nil {
+		t.Fatalf("dialContext() to trusted address error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestClient(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://10.0.0.1/internal", http.StatusFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := mustGuard(t).Client(time.Second, 3).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Get() of a loopback URL succeeded, want it blocked")
+	}
+	if !errors.Is(err, ErrBlocked) || requests != 0 {
+		t.Errorf("Get() error = %v after %d requests, want ErrBlocked before any request", err, requests)
+	}
+
+	// The server itself is trusted, the redirect target is not.
+	client := mustGuard(t, server.Listener.Addr().(*net.TCPAddr).IP.String()).Client(time.Second, 3)
+	resp, err = client.Get(server.URL + "/redirect")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Get() following a redirect to a blocked address succeeded")
+	}
+	if !errors.Is(err, ErrBlocked) {
+		t.Errorf("Get() error = %v, want ErrBlocked", err)
+	}
+}
+
+func mustGuard(t *testing.T, trusted ...string) *Guard {
+	t.Helper()
+	g, err := NewGuard([]string{"http", "https"}, trusted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}